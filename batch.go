@@ -0,0 +1,584 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// DynamoDB limit of keys in a single BatchGetItem request
+	BatchGetLimit int = 100
+
+	// Retries of unprocessed keys or items before giving up
+	BatchMaxRetries int = 8
+
+	batchBackoffBase time.Duration = 50 * time.Millisecond
+	batchBackoffMax  time.Duration = 5 * time.Second
+)
+
+var (
+	ErrorTableNotRegistered error = errors.New("Table is not registered")
+	ErrorBatchUnprocessed   error = errors.New("Batch has unprocessed keys or items left after all the retries")
+	ErrorBatchDuplicateKey  error = errors.New("Batch already has a put or delete of the same key")
+)
+
+// Batch get of items by primary key across any registered tables, e.g.
+//
+//	items, err := d.BatchGet().
+//		Add("users", "1").
+//		Add("users", "2").
+//		Add("events", "1", "2017-01-01").
+//		All()
+//	users := items["users"]
+type BatchGet struct {
+	dytona *Dytona
+
+	tables         map[string]*Table
+	keys           []batchKey
+	seen           map[string]bool
+	consistentRead bool
+	withDeleted    bool
+	err            error
+}
+
+type batchKey struct {
+	table *Table
+	key   map[string]*dynamodb.AttributeValue
+}
+
+// Starting a batch get
+func (d *Dytona) BatchGet() *BatchGet {
+	return &BatchGet{
+		dytona: d,
+		tables: make(map[string]*Table),
+		seen:   make(map[string]bool),
+	}
+}
+
+// Adding a key of the registered table, the same key added twice is fetched once
+func (b *BatchGet) Add(tableName string, hashKey interface{}, rangeKey ...interface{}) *BatchGet {
+	if b.err != nil {
+		return b
+	}
+
+	t := b.dytona.Table(tableName)
+	if t == nil {
+		b.err = fmt.Errorf("%w: '%s'", ErrorTableNotRegistered, tableName)
+		return b
+	}
+
+	var r interface{}
+	if len(rangeKey) > 0 {
+		r = rangeKey[0]
+	}
+
+	key, err := t.key(hashKey, r)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	id := t.Name() + "/" + fmt.Sprint(key)
+	if b.seen[id] {
+		return b
+	}
+
+	b.seen[id] = true
+	b.tables[t.Name()] = t
+	b.keys = append(b.keys, batchKey{table: t, key: key})
+
+	return b
+}
+
+func (b *BatchGet) ConsistentRead() *BatchGet {
+	b.consistentRead = true
+	return b
+}
+
+// Including soft-deleted items into the results
+func (b *BatchGet) WithDeleted() *BatchGet {
+	b.withDeleted = true
+	return b
+}
+
+// Fetching all the items grouped by table name, missing items are skipped,
+// the order of items within a table is not preserved
+func (b *BatchGet) All() (map[string][]Itemer, error) {
+	return b.AllWithContext(context.Background())
+}
+
+// Same as All() bound to the context
+func (b *BatchGet) AllWithContext(ctx context.Context) (map[string][]Itemer, error) {
+	var items map[string][]Itemer = make(map[string][]Itemer)
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	session := b.dytona.GetSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	for _, input := range b.inputs() {
+		requestItems := input.RequestItems
+
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			if attempt > BatchMaxRetries {
+				return items, ErrorBatchUnprocessed
+			}
+
+			if attempt > 0 {
+				if err := aws.SleepWithContext(ctx, batchBackoff(attempt)); err != nil {
+					return items, err
+				}
+			}
+
+			out, err := session.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				return items, classify(err)
+			}
+
+			for tableName, avs := range out.Responses {
+				t := b.tables[tableName]
+
+				for _, av := range avs {
+					if !b.withDeleted && !t.withDeleted && t.isDeleted(av) {
+						continue
+					}
+
+					item, err := t.decodeItem(av)
+					if err != nil {
+						return items, err
+					}
+
+					items[tableName] = append(items[tableName], item)
+				}
+			}
+
+			requestItems = out.UnprocessedKeys
+		}
+	}
+
+	return items, nil
+}
+
+// Splitting the keys into requests of up to BatchGetLimit keys
+func (b *BatchGet) inputs() []*dynamodb.BatchGetItemInput {
+	var inputs []*dynamodb.BatchGetItemInput
+
+	for start := 0; start < len(b.keys); start += BatchGetLimit {
+		end := start + BatchGetLimit
+		if end > len(b.keys) {
+			end = len(b.keys)
+		}
+
+		requestItems := make(map[string]*dynamodb.KeysAndAttributes)
+
+		for _, k := range b.keys[start:end] {
+			name := k.table.Name()
+
+			if requestItems[name] == nil {
+				requestItems[name] = &dynamodb.KeysAndAttributes{ConsistentRead: aws.Bool(b.consistentRead)}
+			}
+
+			requestItems[name].Keys = append(requestItems[name].Keys, k.key)
+		}
+
+		inputs = append(inputs, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+	}
+
+	return inputs
+}
+
+// Exponential backoff for retrying unprocessed keys or items
+func batchBackoff(attempt int) time.Duration {
+	backoff := batchBackoffBase << uint(attempt-1)
+	if backoff <= 0 || backoff > batchBackoffMax {
+		return batchBackoffMax
+	}
+
+	return backoff
+}
+
+const (
+	// DynamoDB limit of put and delete requests in a single BatchWriteItem request
+	BatchWriteLimit int = 25
+
+	// Number of BatchWriteItem requests run at the same time by default
+	BatchWriteConcurrency int = 4
+)
+
+// Single put or delete which was not written, Item is nil for deletes.
+// Puts which were written but failed their AfterSave() hook come with *AfterSaveError.
+type BatchWriteFailure struct {
+	TableName string
+	Key       map[string]*dynamodb.AttributeValue
+	Item      Itemer
+	Err       error
+}
+
+// Returned by BatchWrite.Run() when some of the puts or deletes were not written,
+// the rest of them were written successfully
+type BatchWriteError struct {
+	Failures []*BatchWriteFailure
+}
+
+func (e *BatchWriteError) Error() string {
+	return fmt.Sprintf("Batch write failed for %d items, first error: %s", len(e.Failures), e.Failures[0].Err.Error())
+}
+
+// Bulk put and delete of items across any registered tables, e.g.
+//
+//	err := d.BatchWrite().
+//		Put(user1, user2).
+//		Delete("users", "3").
+//		Concurrency(8).
+//		Run()
+//
+// Puts run BeforeSave hooks, validation and stamp timestamps the same way Save() does,
+// but are unconditional, so versioned items are overwritten regardless of their version.
+type BatchWrite struct {
+	dytona *Dytona
+
+	requests    []*batchWriteRequest
+	seen        map[string]bool
+	failures    []*BatchWriteFailure
+	concurrency int
+}
+
+type batchWriteRequest struct {
+	tableName string
+	key       map[string]*dynamodb.AttributeValue
+	request   *dynamodb.WriteRequest
+
+	// put item with its encoded attributes and version field, nil for deletes
+	item         *Item
+	av           map[string]*dynamodb.AttributeValue
+	versionValue reflect.Value
+	versioned    bool
+
+	// reverting the put item's Id and timestamps while it's not written and setting them back once it is
+	unstamp func()
+	stamp   func()
+}
+
+// Starting a batch write
+func (d *Dytona) BatchWrite() *BatchWrite {
+	return &BatchWrite{
+		dytona:      d,
+		seen:        make(map[string]bool),
+		concurrency: BatchWriteConcurrency,
+	}
+}
+
+// Adding items bound to their tables to be put, items failed to encode
+// or validate are reported by Run()
+func (b *BatchWrite) Put(items ...Itemer) *BatchWrite {
+	for _, item := range items {
+		r, err := newBatchPutRequest(item.base())
+		if err != nil {
+			b.failures = append(b.failures, &BatchWriteFailure{TableName: item.base().tableName, Key: r.key, Item: item, Err: err})
+			continue
+		}
+
+		b.add(r)
+	}
+
+	return b
+}
+
+// Adding a key of the registered table to be deleted
+func (b *BatchWrite) Delete(tableName string, hashKey interface{}, rangeKey ...interface{}) *BatchWrite {
+	t := b.dytona.Table(tableName)
+	if t == nil {
+		b.failures = append(b.failures, &BatchWriteFailure{
+			TableName: tableName,
+			Err:       fmt.Errorf("%w: '%s'", ErrorTableNotRegistered, tableName),
+		})
+		return b
+	}
+
+	var r interface{}
+	if len(rangeKey) > 0 {
+		r = rangeKey[0]
+	}
+
+	key, err := t.key(hashKey, r)
+	if err != nil {
+		b.failures = append(b.failures, &BatchWriteFailure{TableName: t.Name(), Key: key, Err: err})
+		return b
+	}
+
+	b.add(&batchWriteRequest{
+		tableName: t.Name(),
+		key:       key,
+		request:   &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: key}},
+	})
+
+	return b
+}
+
+// Setting the number of BatchWriteItem requests run at the same time
+func (b *BatchWrite) Concurrency(n int) *BatchWrite {
+	if n > 0 {
+		b.concurrency = n
+	}
+
+	return b
+}
+
+// Writing all the puts and deletes, *BatchWriteError is returned
+// with every put or delete which was not written
+func (b *BatchWrite) Run() error {
+	return b.RunWithContext(context.Background())
+}
+
+// Same as Run() bound to the context
+func (b *BatchWrite) RunWithContext(ctx context.Context) error {
+	var (
+		chunks chan []*batchWriteRequest = make(chan []*batchWriteRequest)
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+
+		// failures of a previous run are not carried over, only the ones of Put() and Delete()
+		failures []*BatchWriteFailure = append([]*BatchWriteFailure{}, b.failures...)
+	)
+
+	session := b.dytona.GetSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	for w := 0; w < b.concurrency; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for chunk := range chunks {
+				chunkFailures := b.write(ctx, session, chunk)
+
+				mu.Lock()
+				failures = append(failures, chunkFailures...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, chunk := range b.chunks() {
+		chunks <- chunk
+	}
+	close(chunks)
+
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return &BatchWriteError{Failures: failures}
+	}
+
+	return nil
+}
+
+// A later put or delete of the same key is reported as failed,
+// DynamoDB rejects batches with duplicate keys
+func (b *BatchWrite) add(r *batchWriteRequest) {
+	id := r.tableName + "/" + fmt.Sprint(r.key)
+
+	if b.seen[id] {
+		b.failures = append(b.failures, notWritten([]*batchWriteRequest{r}, ErrorBatchDuplicateKey)...)
+		return
+	}
+
+	b.seen[id] = true
+	b.requests = append(b.requests, r)
+}
+
+// Splitting the requests into chunks of up to BatchWriteLimit requests
+func (b *BatchWrite) chunks() [][]*batchWriteRequest {
+	var chunks [][]*batchWriteRequest
+
+	for start := 0; start < len(b.requests); start += BatchWriteLimit {
+		end := start + BatchWriteLimit
+		if end > len(b.requests) {
+			end = len(b.requests)
+		}
+
+		chunks = append(chunks, b.requests[start:end])
+	}
+
+	return chunks
+}
+
+// Writing a single chunk retrying unprocessed items, the failed requests are returned
+func (b *BatchWrite) write(ctx context.Context, session *dynamodb.DynamoDB, chunk []*batchWriteRequest) (failures []*BatchWriteFailure) {
+	var pending []*batchWriteRequest = chunk
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > BatchMaxRetries {
+			return append(failures, notWritten(pending, ErrorBatchUnprocessed)...)
+		}
+
+		if attempt > 0 {
+			if err := aws.SleepWithContext(ctx, batchBackoff(attempt)); err != nil {
+				return append(failures, notWritten(pending, err)...)
+			}
+		}
+
+		requestItems := make(map[string][]*dynamodb.WriteRequest)
+		for _, r := range pending {
+			requestItems[r.tableName] = append(requestItems[r.tableName], r.request)
+		}
+
+		out, err := session.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{RequestItems: requestItems})
+		if err != nil {
+			return append(failures, notWritten(pending, classify(err))...)
+		}
+
+		var unprocessed []*batchWriteRequest
+
+		for _, r := range pending {
+			if r.unprocessed(out.UnprocessedItems[r.tableName]) {
+				unprocessed = append(unprocessed, r)
+				continue
+			}
+
+			if err := r.written(); err != nil {
+				failures = append(failures, batchWriteFailures([]*batchWriteRequest{r}, err)...)
+			}
+		}
+
+		pending = unprocessed
+	}
+
+	return failures
+}
+
+func newBatchPutRequest(i *Item) (*batchWriteRequest, error) {
+	var r *batchWriteRequest = &batchWriteRequest{tableName: i.tableName, item: i}
+
+	if i.item == nil {
+		return r, ErrorItemNotSet
+	}
+
+	if i.tableName == "" {
+		return r, ErrorNoTableName
+	}
+
+	if err := beforeSave(i.item); err != nil {
+		return r, err
+	}
+
+	if err := i.validate(); err != nil {
+		return r, err
+	}
+
+	r.unstamp = i.touch(time.Now().UTC())
+	r.stamp = i.stamps()
+
+	av, err := i.Marshal()
+	if err != nil {
+		r.unstamp()
+		return r, err
+	}
+
+	if r.key, err = i.key(av); err != nil {
+		r.unstamp()
+		return r, err
+	}
+
+	versionValue, versionName, versioned, err := i.versionField()
+	if err != nil {
+		r.unstamp()
+		return r, err
+	}
+
+	if versioned {
+		av[versionName] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(versionValue.Int()+1, 10))}
+	}
+
+	r.av = av
+	r.versionValue = versionValue
+	r.versioned = versioned
+	r.request = &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: av}}
+
+	return r, nil
+}
+
+// Checking whether the request is among the table's unprocessed ones by its key
+func (r *batchWriteRequest) unprocessed(requests []*dynamodb.WriteRequest) bool {
+	for _, wr := range requests {
+		var av map[string]*dynamodb.AttributeValue
+
+		switch {
+		case wr.PutRequest != nil:
+			av = wr.PutRequest.Item
+		case wr.DeleteRequest != nil:
+			av = wr.DeleteRequest.Key
+		}
+
+		matches := true
+		for name, v := range r.key {
+			if !reflect.DeepEqual(v, av[name]) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Updating a put item the same way a successful Save() does
+func (r *batchWriteRequest) written() error {
+	if r.item == nil {
+		return nil
+	}
+
+	// a previous run could have failed to write it
+	r.stamp()
+
+	if r.versioned {
+		r.versionValue.SetInt(r.versionValue.Int() + 1)
+	}
+
+	r.item.resetChanges(r.av)
+
+	return afterSave(r.item.item)
+}
+
+// Failing the requests which were not written, put items get their previous Id and timestamps back
+func notWritten(requests []*batchWriteRequest, err error) []*BatchWriteFailure {
+	for _, r := range requests {
+		if r.unstamp != nil {
+			r.unstamp()
+		}
+	}
+
+	return batchWriteFailures(requests, err)
+}
+
+func batchWriteFailures(requests []*batchWriteRequest, err error) []*BatchWriteFailure {
+	failures := make([]*BatchWriteFailure, len(requests))
+
+	for n, r := range requests {
+		failures[n] = &BatchWriteFailure{TableName: r.tableName, Key: r.key, Err: err}
+		if r.item != nil {
+			failures[n].Item = r.item.item
+		}
+	}
+
+	return failures
+}
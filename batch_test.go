@@ -0,0 +1,201 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchGetInputs(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	type Event struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date string `json:"date" dynamodbav:"date" dynamodbpk:"RANGE"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.RegisterTable("users", func() Itemer { return &User{} })
+	d.RegisterTable("events", func() Itemer { return &Event{} })
+
+	b := d.BatchGet()
+	for n := 0; n < 150; n++ {
+		b.Add("users", fmt.Sprint(n))
+	}
+	b.Add("users", "1")
+	b.Add("Events", "1", "2017-01-01")
+
+	inputs := b.inputs()
+	if assert.Len(t, inputs, 2) {
+		assert.Len(t, inputs[0].RequestItems["users"].Keys, 100)
+		assert.Len(t, inputs[1].RequestItems["users"].Keys, 50)
+		assert.Len(t, inputs[1].RequestItems["events"].Keys, 1)
+		assert.False(t, *inputs[1].RequestItems["events"].ConsistentRead)
+	}
+
+	_, err := d.BatchGet().Add("accounts", "1").Add("users", "1").All()
+	assert.True(t, errors.Is(err, ErrorTableNotRegistered))
+	assert.EqualError(t, err, "Table is not registered: 'accounts'")
+
+	_, err = d.BatchGet().Add("events", "1").All()
+	assert.Equal(t, &MissingKeyError{AttributeName: "date", KeyType: KeyTypeRANGE}, err)
+
+	_, err = d.BatchGet().Add("users", "1").All()
+	assert.Equal(t, ErrorNoSession, err)
+}
+
+func TestBatchBackoff(t *testing.T) {
+	assert.Equal(t, 50*time.Millisecond, batchBackoff(1))
+	assert.Equal(t, 100*time.Millisecond, batchBackoff(2))
+	assert.Equal(t, 3200*time.Millisecond, batchBackoff(7))
+	assert.Equal(t, 5*time.Second, batchBackoff(8))
+	assert.Equal(t, 5*time.Second, batchBackoff(100))
+}
+
+func TestBatchGet(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	b := d.BatchGet()
+	for n := 0; n < 120; n++ {
+		u := tbl.NewItem().(*User)
+		u.Id = fmt.Sprint(n)
+		assert.Nil(t, u.Save())
+
+		b.Add("users", u.Id)
+	}
+	b.Add("users", "missing")
+
+	items, err := b.All()
+	assert.Nil(t, err)
+	if assert.Len(t, items["users"], 120) {
+		assert.IsType(t, &User{}, items["users"][0])
+	}
+}
+
+func TestBatchWriteChunks(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name" validate:"required"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	tbl := d.RegisterTable("users", func() Itemer { return &User{} })
+
+	b := d.BatchWrite()
+	for n := 0; n < 60; n++ {
+		u := tbl.NewItem().(*User)
+		u.Id = fmt.Sprint(n)
+		u.Name = "Roman"
+		b.Put(u)
+	}
+	b.Delete("users", "0")
+	b.Delete("users", "60")
+
+	chunks := b.chunks()
+	if assert.Len(t, chunks, 3) {
+		assert.Len(t, chunks[0], 25)
+		assert.Len(t, chunks[1], 25)
+		assert.Len(t, chunks[2], 11)
+		assert.NotNil(t, chunks[0][0].request.PutRequest)
+		assert.NotNil(t, chunks[2][10].request.DeleteRequest)
+	}
+
+	if assert.Len(t, b.failures, 1) {
+		assert.Equal(t, ErrorBatchDuplicateKey, b.failures[0].Err, "Later delete of the same key is rejected")
+		assert.Nil(t, b.failures[0].Item)
+	}
+
+	invalid := tbl.NewItem().(*User)
+	b = d.BatchWrite().Put(invalid).Delete("accounts", "1")
+	if assert.Len(t, b.failures, 2) {
+		assert.Equal(t, invalid, b.failures[0].Item)
+		assert.IsType(t, &ValidationError{}, b.failures[0].Err)
+		assert.True(t, errors.Is(b.failures[1].Err, ErrorTableNotRegistered))
+	}
+
+	u1 := tbl.NewItem().(*User)
+	u1.Id = "1"
+	u1.Name = "Roman"
+	u2 := tbl.NewItem().(*User)
+	u2.Id = "1"
+	u2.Name = "Roman"
+	b = d.BatchWrite().Put(u1).Put(u2)
+	if assert.Len(t, b.failures, 1) {
+		assert.Equal(t, ErrorBatchDuplicateKey, b.failures[0].Err)
+		assert.True(t, u2.CreatedAt.IsZero(), "Not written put should not touch the item")
+		assert.True(t, u2.UpdatedAt.IsZero())
+		assert.False(t, u1.CreatedAt.IsZero())
+	}
+
+	assert.Equal(t, ErrorNoSession, b.Run())
+}
+
+func TestBatchWrite(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Version int    `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	var users []Itemer
+	for n := 0; n < 60; n++ {
+		u := tbl.NewItem().(*User)
+		u.Id = fmt.Sprint(n)
+		users = append(users, u)
+	}
+
+	assert.Nil(t, d.BatchWrite().Put(users...).Concurrency(2).Run())
+	assert.Equal(t, 1, users[0].(*User).Version)
+
+	items, err := tbl.Scan().All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 60)
+
+	b := d.BatchWrite()
+	for n := 0; n < 30; n++ {
+		b.Delete("users", fmt.Sprint(n))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, b.RunWithContext(ctx))
+	assert.Nil(t, b.Run(), "Failures of the previous run should not be reported again")
+
+	items, err = tbl.Scan().All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 30)
+}
@@ -0,0 +1,305 @@
+package dytona
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Part of the table schema a change belongs to
+type SchemaPart string
+
+const (
+	SchemaPartKeySchema            SchemaPart = "key schema"
+	SchemaPartAttributeDefinition  SchemaPart = "attribute"
+	SchemaPartLocalSecondaryIndex  SchemaPart = "local secondary index"
+	SchemaPartGlobalSecondaryIndex SchemaPart = "global secondary index"
+	SchemaPartThroughput           SchemaPart = "throughput"
+	SchemaPartTimeToLive           SchemaPart = "time to live"
+	SchemaPartStream               SchemaPart = "stream"
+)
+
+// What has to be done to the live table to match the declared one
+type SchemaAction string
+
+const (
+	SchemaActionAdd    SchemaAction = "add"
+	SchemaActionRemove SchemaAction = "remove"
+	SchemaActionModify SchemaAction = "modify"
+)
+
+// Single difference between the declared and the live table.
+// Name is the index or attribute name, empty for the parts of the table itself,
+// Declared and Live are readable descriptions of the part, empty when it is absent.
+type SchemaChange struct {
+	Part     SchemaPart
+	Action   SchemaAction
+	Name     string
+	Declared string
+	Live     string
+}
+
+func (c *SchemaChange) String() string {
+	subject := string(c.Part)
+	if c.Name != "" {
+		subject += fmt.Sprintf(" '%s'", c.Name)
+	}
+
+	switch c.Action {
+	case SchemaActionAdd:
+		return fmt.Sprintf("%s is missing, declared %s", subject, c.Declared)
+	case SchemaActionRemove:
+		return fmt.Sprintf("%s is not declared, live %s", subject, c.Live)
+	}
+
+	return fmt.Sprintf("%s is %s, declared %s", subject, c.Live, c.Declared)
+}
+
+// Differences between the table declared by the item's struct tags and the live one,
+// in the order of key schema, attributes, indexes, throughput, TTL and stream
+type SchemaDiff struct {
+	TableName string
+	Changes   []*SchemaChange
+}
+
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Human readable report with a change per line, e.g.
+//
+//	Table 'users' differs from its declaration:
+//	  - global secondary index 'EmailGsi' is missing, declared [email HASH] ALL
+func (d *SchemaDiff) String() string {
+	if d.Empty() {
+		return fmt.Sprintf("Table '%s' matches its declaration", d.TableName)
+	}
+
+	lines := []string{fmt.Sprintf("Table '%s' differs from its declaration:", d.TableName)}
+	for _, c := range d.Changes {
+		lines = append(lines, "  - "+c.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Comparing every registered table with its live one, the diffs are in the order of table names
+func (d *Dytona) DiffTables() ([]*SchemaDiff, error) {
+	return d.DiffTablesWithContext(context.Background())
+}
+
+// Same as DiffTables() bound to the context
+func (d *Dytona) DiffTablesWithContext(ctx context.Context) ([]*SchemaDiff, error) {
+	var diffs []*SchemaDiff
+
+	for _, t := range d.tables() {
+		diff, err := t.DiffWithContext(ctx)
+		if err != nil {
+			return diffs, err
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// Comparing the table declared by the item's struct tags with the live one,
+// ErrTableNotFound is returned for a missing table
+func (t *Table) Diff() (*SchemaDiff, error) {
+	return t.DiffWithContext(context.Background())
+}
+
+// Same as Diff() bound to the context
+func (t *Table) DiffWithContext(ctx context.Context) (*SchemaDiff, error) {
+	live, err := t.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.diff(ctx, live)
+}
+
+func (t *Table) diff(ctx context.Context, live *dynamodb.TableDescription) (*SchemaDiff, error) {
+	liveTimeToLive, err := t.describeTimeToLive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSchema(t.declared, live, t.timeToLive, liveTimeToLive), nil
+}
+
+// Getting the attribute of enabled TTL, empty when it's disabled
+func (t *Table) describeTimeToLive(ctx context.Context) (string, error) {
+	session := t.getSession()
+	if session == nil {
+		return "", ErrorNoSession
+	}
+
+	out, err := session.DescribeTimeToLiveWithContext(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: t.declared.TableName,
+	})
+	if err != nil {
+		return "", classify(err)
+	}
+
+	if out.TimeToLiveDescription == nil {
+		return "", nil
+	}
+
+	switch aws.StringValue(out.TimeToLiveDescription.TimeToLiveStatus) {
+	case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+		return aws.StringValue(out.TimeToLiveDescription.AttributeName), nil
+	}
+
+	return "", nil
+}
+
+func diffSchema(declared, live *dynamodb.TableDescription, declaredTimeToLive, liveTimeToLive string) *SchemaDiff {
+	var (
+		d                      *SchemaDiff       = &SchemaDiff{TableName: aws.StringValue(declared.TableName)}
+		declaredAttributes     map[string]string = make(map[string]string)
+		liveAttributes         map[string]string = make(map[string]string)
+		declaredLsis, liveLsis map[string]string = make(map[string]string), make(map[string]string)
+		declaredGsis, liveGsis map[string]string = make(map[string]string), make(map[string]string)
+		declaredGsiThroughput  map[string]string = make(map[string]string)
+		liveGsiThroughput      map[string]string = make(map[string]string)
+	)
+
+	d.add(SchemaPartKeySchema, "", keySchemaString(declared.KeySchema), keySchemaString(live.KeySchema))
+
+	for _, a := range declared.AttributeDefinitions {
+		declaredAttributes[aws.StringValue(a.AttributeName)] = aws.StringValue(a.AttributeType)
+	}
+	for _, a := range live.AttributeDefinitions {
+		liveAttributes[aws.StringValue(a.AttributeName)] = aws.StringValue(a.AttributeType)
+	}
+	d.addAll(SchemaPartAttributeDefinition, declaredAttributes, liveAttributes)
+
+	for _, lsi := range declared.LocalSecondaryIndexes {
+		declaredLsis[aws.StringValue(lsi.IndexName)] = indexString(lsi.KeySchema, lsi.Projection)
+	}
+	for _, lsi := range live.LocalSecondaryIndexes {
+		liveLsis[aws.StringValue(lsi.IndexName)] = indexString(lsi.KeySchema, lsi.Projection)
+	}
+	d.addAll(SchemaPartLocalSecondaryIndex, declaredLsis, liveLsis)
+
+	for _, gsi := range declared.GlobalSecondaryIndexes {
+		declaredGsis[aws.StringValue(gsi.IndexName)] = indexString(gsi.KeySchema, gsi.Projection)
+		declaredGsiThroughput[aws.StringValue(gsi.IndexName)] = throughputString(gsi.ProvisionedThroughput)
+	}
+	for _, gsi := range live.GlobalSecondaryIndexes {
+		liveGsis[aws.StringValue(gsi.IndexName)] = indexString(gsi.KeySchema, gsi.Projection)
+		liveGsiThroughput[aws.StringValue(gsi.IndexName)] = throughputString(gsi.ProvisionedThroughput)
+	}
+	d.addAll(SchemaPartGlobalSecondaryIndex, declaredGsis, liveGsis)
+
+	d.add(SchemaPartThroughput, "", throughputString(declared.ProvisionedThroughput), throughputString(live.ProvisionedThroughput))
+
+	// throughput of missing or not declared indexes is a part of their addition or removal
+	for _, name := range sortedKeys(declaredGsiThroughput) {
+		if _, ok := liveGsiThroughput[name]; ok {
+			d.add(SchemaPartThroughput, name, declaredGsiThroughput[name], liveGsiThroughput[name])
+		}
+	}
+
+	d.add(SchemaPartTimeToLive, "", declaredTimeToLive, liveTimeToLive)
+	d.add(SchemaPartStream, "", streamString(declared.StreamSpecification), streamString(live.StreamSpecification))
+
+	return d
+}
+
+// Adding a change when the declared and the live parts differ
+func (d *SchemaDiff) add(part SchemaPart, name, declared, live string) {
+	if declared == live {
+		return
+	}
+
+	action := SchemaActionModify
+	switch {
+	case live == "":
+		action = SchemaActionAdd
+	case declared == "":
+		action = SchemaActionRemove
+	}
+
+	d.Changes = append(d.Changes, &SchemaChange{
+		Part:     part,
+		Action:   action,
+		Name:     name,
+		Declared: declared,
+		Live:     live,
+	})
+}
+
+// Adding changes of named parts, in the order of names
+func (d *SchemaDiff) addAll(part SchemaPart, declared, live map[string]string) {
+	names := sortedKeys(declared)
+	for _, name := range sortedKeys(live) {
+		if _, ok := declared[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		d.add(part, name, declared[name], live[name])
+	}
+}
+
+func keySchemaString(keySchema []*dynamodb.KeySchemaElement) string {
+	s := ""
+	for _, k := range keySchema {
+		s += fmt.Sprintf("[%s %s]", aws.StringValue(k.AttributeName), aws.StringValue(k.KeyType))
+	}
+
+	return s
+}
+
+func indexString(keySchema []*dynamodb.KeySchemaElement, projection *dynamodb.Projection) string {
+	s := keySchemaString(keySchema)
+	if projection == nil {
+		return s
+	}
+
+	s += " " + aws.StringValue(projection.ProjectionType)
+
+	if len(projection.NonKeyAttributes) > 0 {
+		attributes := aws.StringValueSlice(projection.NonKeyAttributes)
+		sort.Strings(attributes)
+		s += "(" + strings.Join(attributes, ", ") + ")"
+	}
+
+	return s
+}
+
+func throughputString(throughput *dynamodb.ProvisionedThroughputDescription) string {
+	if throughput == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("read %d, write %d", aws.Int64Value(throughput.ReadCapacityUnits), aws.Int64Value(throughput.WriteCapacityUnits))
+}
+
+// Stream view type, empty for disabled stream
+func streamString(stream *dynamodb.StreamSpecification) string {
+	if stream == nil || !aws.BoolValue(stream.StreamEnabled) {
+		return ""
+	}
+
+	return aws.StringValue(stream.StreamViewType)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
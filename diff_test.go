@@ -0,0 +1,160 @@
+package dytona
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func changeStrings(diff *SchemaDiff) []string {
+	var s []string
+	for _, c := range diff.Changes {
+		s = append(s, c.String())
+	}
+
+	return s
+}
+
+func TestDiffSchema(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Email   string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,10,2"`
+		Expires int64  `json:"expires" dynamodbav:"expires" dynamodbttl:""`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithStream(StreamViewTypeNEWIMAGE)
+
+	declared := tbl.Description()
+	assert.Equal(t, "expires", tbl.timeToLive)
+	assert.True(t, diffSchema(&declared, &declared, "expires", "expires").Empty())
+
+	live := &dynamodb.TableDescription{
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(KeyTypeHASH)},
+			{AttributeName: aws.String("c_at"), KeyType: aws.String(KeyTypeRANGE)},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String(AttributeTypeN)},
+			{AttributeName: aws.String("c_at"), AttributeType: aws.String(AttributeTypeS)},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{
+				IndexName: aws.String("NameGsi"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("name"), KeyType: aws.String(KeyTypeHASH)},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType:   aws.String(KeyProjectionTypeINCLUDE),
+					NonKeyAttributes: aws.StringSlice([]string{"email", "age"}),
+				},
+			},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(StreamViewTypeKEYSONLY),
+		},
+	}
+
+	diff := diffSchema(&declared, live, "expires", "")
+	assert.False(t, diff.Empty())
+	assert.Equal(t, &SchemaChange{
+		Part:     SchemaPartGlobalSecondaryIndex,
+		Action:   SchemaActionAdd,
+		Name:     "EmailGsi",
+		Declared: "[email HASH] ALL",
+	}, diff.Changes[4])
+	assert.Equal(t, []string{
+		"key schema is [id HASH][c_at RANGE], declared [id HASH]",
+		"attribute 'c_at' is not declared, live S",
+		"attribute 'email' is missing, declared S",
+		"attribute 'id' is N, declared S",
+		"global secondary index 'EmailGsi' is missing, declared [email HASH] ALL",
+		"global secondary index 'NameGsi' is not declared, live [name HASH] INCLUDE(age, email)",
+		"throughput is read 1, write 1, declared read 5, write 5",
+		"time to live is missing, declared expires",
+		"stream is KEYS_ONLY, declared NEW_IMAGE",
+	}, changeStrings(diff))
+
+	assert.Equal(t, "Table 'users' differs from its declaration:\n"+
+		"  - key schema is [id HASH][c_at RANGE], declared [id HASH]\n"+
+		"  - attribute 'c_at' is not declared, live S\n"+
+		"  - attribute 'email' is missing, declared S\n"+
+		"  - attribute 'id' is N, declared S\n"+
+		"  - global secondary index 'EmailGsi' is missing, declared [email HASH] ALL\n"+
+		"  - global secondary index 'NameGsi' is not declared, live [name HASH] INCLUDE(age, email)\n"+
+		"  - throughput is read 1, write 1, declared read 5, write 5\n"+
+		"  - time to live is missing, declared expires\n"+
+		"  - stream is KEYS_ONLY, declared NEW_IMAGE", diff.String())
+
+	assert.Equal(t, "Table 'users' matches its declaration", (&SchemaDiff{TableName: "users"}).String())
+}
+
+func TestDiffSchemaIndexThroughput(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,10,2"`
+	}
+
+	declared := NewTable("users", func() Itemer {
+		return &User{}
+	}).Description()
+
+	live := declared
+	live.GlobalSecondaryIndexes = []*dynamodb.GlobalSecondaryIndexDescription{
+		{
+			IndexName:  aws.String("EmailGsi"),
+			KeySchema:  declared.GlobalSecondaryIndexes[0].KeySchema,
+			Projection: declared.GlobalSecondaryIndexes[0].Projection,
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(5),
+			},
+		},
+	}
+
+	assert.Equal(t, []*SchemaChange{
+		{
+			Part:     SchemaPartThroughput,
+			Action:   SchemaActionModify,
+			Name:     "EmailGsi",
+			Declared: "read 10, write 2",
+			Live:     "read 5, write 5",
+		},
+	}, diffSchema(&declared, &live, "", "").Changes)
+}
+
+func TestDiffTables(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Expires int64 `json:"expires" dynamodbav:"expires" dynamodbttl:""`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	_, err := d.DiffTables()
+	assert.True(t, errors.Is(err, ErrTableNotFound))
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	diffs, err := d.DiffTables()
+	if assert.Nil(t, err) && assert.Len(t, diffs, 1) {
+		assert.Equal(t, []string{"time to live is missing, declared expires"}, changeStrings(diffs[0]))
+	}
+}
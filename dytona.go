@@ -1,8 +1,17 @@
+// Package dytona is a DynamoDB ORM on top of aws-sdk-go.
+//
+// It requires Go 1.13 or newer, since errors are wrapped with %w to be checked with
+// errors.Is and errors.As, and aws-sdk-go v1.28.0 or newer, the first one returning
+// cancellation reasons as *dynamodb.TransactionCanceledException, the vendored version
+// is pinned in vendor/vendor.json.
 package dytona
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -10,7 +19,10 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-var ErrorAlreadyDialed error = errors.New("DynamoDB connection already dialed")
+var (
+	ErrorAlreadyDialed     error = errors.New("DynamoDB connection already dialed")
+	ErrorAlreadyRegistered error = errors.New("Table is already registered")
+)
 
 func NewDytona(id, secret, endpoint, region string) *Dytona {
 	return &Dytona{
@@ -26,27 +38,126 @@ func NewConfig() *aws.Config {
 	return aws.NewConfig()
 }
 
+// Connection and registry of tables, safe for concurrent use
 type Dytona struct {
-	config   *aws.Config
+	config *aws.Config
+	cfgs   []*aws.Config
+
+	// guards the session, the registry and the id strategy
+	mu       sync.RWMutex
 	session  *dynamodb.DynamoDB
 	registry map[string]*Table
+	idFunc   IdFunc
 }
 
+// Creating a session, registered tables use it whenever they were registered
 func (d *Dytona) Dial(cfgs ...*aws.Config) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.session != nil {
 		return ErrorAlreadyDialed
 	}
 
+	d.cfgs = cfgs
+	d.session = dynamodb.New(session.New(d.config), cfgs...)
+
+	return nil
+}
+
+// Dialing and making sure DynamoDB can be reached within the context,
+// the session is dropped if it can't
+func (d *Dytona) DialWithContext(ctx context.Context, cfgs ...*aws.Config) error {
+	if err := d.Dial(cfgs...); err != nil {
+		return err
+	}
+
+	if err := d.PingWithContext(ctx); err != nil {
+		d.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Replacing the session with a new one, the configs of the last Dial() are used if none given
+func (d *Dytona) Redial(cfgs ...*aws.Config) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(cfgs) == 0 {
+		cfgs = d.cfgs
+	}
+
+	d.cfgs = cfgs
 	d.session = dynamodb.New(session.New(d.config), cfgs...)
 
 	return nil
 }
 
+// Dropping the session, operations of the registered tables fail with ErrorNoSession
+// until Dial() is called again. Items created before keep the session they were bound to.
+func (d *Dytona) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.session = nil
+
+	return nil
+}
+
+// Checking DynamoDB can be reached with a minimal ListTables request
+func (d *Dytona) Ping() error {
+	return d.PingWithContext(context.Background())
+}
+
+// Same as Ping() bound to the context
+func (d *Dytona) PingWithContext(ctx context.Context) error {
+	session := d.GetSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	_, err := session.ListTablesWithContext(ctx, &dynamodb.ListTablesInput{Limit: aws.Int64(1)})
+
+	return classify(err)
+}
+
 func (d *Dytona) GetSession() *dynamodb.DynamoDB {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	return d.session
 }
 
+// Setting the strategy for generating an empty `Id` for all the registered tables,
+// a table's own strategy can be set later with Table.WithIdFunc()
+func (d *Dytona) WithIdFunc(idFunc IdFunc) *Dytona {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.idFunc = idFunc
+
+	for _, t := range d.registry {
+		t.WithIdFunc(idFunc)
+	}
+
+	return d
+}
+
+// Registering a table, it uses the Dytona's current session until WithSession() is called on it.
+// Table names are case insensitive, a table registered under the same name is replaced.
 func (d *Dytona) RegisterTable(tableName string, newItemFunc func() Itemer) *Table {
+	t, _ := d.registerTable(tableName, newItemFunc, true)
+	return t
+}
+
+// Same as RegisterTable() but ErrorAlreadyRegistered is returned for a taken name
+func (d *Dytona) TryRegisterTable(tableName string, newItemFunc func() Itemer) (*Table, error) {
+	return d.registerTable(tableName, newItemFunc, false)
+}
+
+func (d *Dytona) registerTable(tableName string, newItemFunc func() Itemer, replace bool) (*Table, error) {
 	item := newItemFunc()
 	if item == nil {
 		panic("dytona.SetTable: item can not be nil")
@@ -54,14 +165,25 @@ func (d *Dytona) RegisterTable(tableName string, newItemFunc func() Itemer) *Tab
 
 	tableName = strings.ToLower(tableName)
 
-	t := NewTable(strings.ToLower(tableName), newItemFunc).
-		WithSession(d.session)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.registry[tableName]; ok && !replace {
+		return nil, fmt.Errorf("%w: '%s'", ErrorAlreadyRegistered, tableName)
+	}
+
+	t := NewTable(tableName, newItemFunc).
+		WithIdFunc(d.idFunc)
+	t.dytona = d
 
 	d.registry[tableName] = t
-	return t
+	return t, nil
 }
 
 func (d *Dytona) Table(tableName string) *Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	return d.registry[strings.ToLower(tableName)]
 }
 
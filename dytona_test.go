@@ -1,6 +1,10 @@
 package dytona
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -62,4 +66,109 @@ func TestRegister(t *testing.T) {
 
 	u := d.Table("users").NewItem()
 	assert.IsType(t, &User{}, u)
+
+	_, err := d.TryRegisterTable("Users", func() Itemer {
+		return &User{}
+	})
+	assert.True(t, errors.Is(err, ErrorAlreadyRegistered))
+	assert.EqualError(t, err, "Table is already registered: 'users'")
+	assert.Equal(t, table, d.Table("users"))
+
+	replaced := d.RegisterTable("Users", func() Itemer {
+		return &User{}
+	})
+	assert.False(t, table == replaced, "Table should be replaced")
+	assert.Equal(t, replaced, d.Table("users"))
+}
+
+func TestRegisterBeforeDial(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+
+	table := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+	assert.Nil(t, table.getSession())
+
+	_, err := table.Get("1")
+	assert.Equal(t, ErrorNoSession, err)
+
+	assert.Nil(t, d.Dial(NewConfig().WithMaxRetries(0)))
+	assert.Equal(t, d.session, table.getSession())
+	assert.Equal(t, d.session, table.NewItem().(*User).session)
+
+	session := d.session
+	assert.Nil(t, d.Redial())
+	assert.NotEqual(t, session, table.getSession())
+	assert.Equal(t, d.session, table.getSession())
+
+	assert.Nil(t, d.Close())
+	assert.Nil(t, table.getSession())
+	assert.Equal(t, ErrorNoSession, d.Ping())
+
+	assert.Nil(t, d.Dial())
+	assert.NotNil(t, table.getSession())
+
+	// explicitly bound tables don't follow the registry
+	table.WithSession(session)
+	assert.Nil(t, d.Redial())
+	assert.Equal(t, session, table.getSession())
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	var wg sync.WaitGroup
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+
+	for n := 0; n < 10; n++ {
+		wg.Add(3)
+
+		go func(n int) {
+			defer wg.Done()
+			d.RegisterTable(fmt.Sprintf("users%d", n), func() Itemer { return &User{} })
+		}(n)
+
+		go func(n int) {
+			defer wg.Done()
+			if tbl := d.Table(fmt.Sprintf("users%d", n)); tbl != nil {
+				tbl.getSession()
+				tbl.NewItem()
+			}
+		}(n)
+
+		go func() {
+			defer wg.Done()
+			d.Redial()
+			d.WithIdFunc(IdULID)
+		}()
+	}
+
+	wg.Wait()
+
+	for n := 0; n < 10; n++ {
+		assert.NotNil(t, d.Table(fmt.Sprintf("users%d", n)))
+	}
+}
+
+func TestDialWithContext(t *testing.T) {
+	d := NewDytona("1", "2", "http://badhost", "us-east-1")
+
+	err := d.DialWithContext(context.Background(), NewConfig().WithMaxRetries(0))
+	assert.NotNil(t, err)
+	assert.Nil(t, d.session, "Session is dropped when DynamoDB can't be reached")
+
+	assert.Equal(t, ErrorNoSession, d.Ping())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = d.DialWithContext(ctx, NewConfig().WithMaxRetries(0))
+	assert.True(t, errors.Is(err, context.Canceled))
 }
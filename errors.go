@@ -0,0 +1,88 @@
+package dytona
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Kinds of errors returned by all the operations, to be checked with errors.Is, e.g.
+//
+//	if errors.Is(err, dytona.ErrNotFound) {
+//		...
+//	}
+//
+// DynamoDB errors are wrapped, the original awserr.Error can be got with errors.As.
+var (
+	ErrNotFound            error = errors.New("Item not found")
+	ErrConditionFailed     error = errors.New("Condition check failed")
+	ErrThrottled           error = errors.New("Request was throttled")
+	ErrTableNotFound       error = errors.New("Table not found")
+	ErrTableExists         error = errors.New("Table already exists")
+	ErrTableInUse          error = errors.New("Table is being created, updated or deleted")
+	ErrValidation          error = errors.New("Validation failed")
+	ErrTransactionCanceled error = errors.New("Transaction canceled")
+)
+
+// DynamoDB error classified by its code
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrapping DynamoDB errors with known codes into *Error, the rest are returned as is
+func classify(err error) error {
+	var awsErr awserr.Error
+
+	if err == nil || !errors.As(err, &awsErr) {
+		return err
+	}
+
+	var kind error
+
+	switch awsErr.Code() {
+	case "ConditionalCheckFailedException":
+		kind = ErrConditionFailed
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		kind = ErrThrottled
+	case "ResourceNotFoundException":
+		kind = ErrTableNotFound
+	case "ResourceInUseException":
+		kind = ErrTableInUse
+	case "ValidationException":
+		kind = ErrValidation
+	case "TransactionCanceledException":
+		kind = ErrTransactionCanceled
+	case "RequestCanceled":
+		// context.Canceled or context.DeadlineExceeded of the canceled context
+		if kind = awsErr.OrigErr(); kind == nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == "ConditionalCheckFailedException"
+}
+
+func isResourceInUse(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == "ResourceInUseException"
+}
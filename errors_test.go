@@ -0,0 +1,81 @@
+package dytona
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	assert.Nil(t, classify(nil))
+
+	plain := errors.New("Plain")
+	assert.Equal(t, plain, classify(plain))
+
+	unknown := awserr.New("RequestError", "send request failed", nil)
+	assert.Equal(t, unknown, classify(unknown))
+
+	for code, kind := range map[string]error{
+		"ConditionalCheckFailedException":        ErrConditionFailed,
+		"ProvisionedThroughputExceededException": ErrThrottled,
+		"ThrottlingException":                    ErrThrottled,
+		"RequestLimitExceeded":                   ErrThrottled,
+		"ResourceNotFoundException":              ErrTableNotFound,
+		"ResourceInUseException":                 ErrTableInUse,
+		"ValidationException":                    ErrValidation,
+		"TransactionCanceledException":           ErrTransactionCanceled,
+	} {
+		err := classify(awserr.New(code, "Message", nil))
+		assert.True(t, errors.Is(err, kind), code)
+
+		var awsErr awserr.Error
+		if assert.True(t, errors.As(err, &awsErr), code) {
+			assert.Equal(t, code, awsErr.Code())
+		}
+	}
+
+	assert.EqualError(t, classify(awserr.New("ResourceNotFoundException", "Requested resource not found", nil)),
+		"Table not found: ResourceNotFoundException: Requested resource not found")
+}
+
+func TestErrorKinds(t *testing.T) {
+	assert.True(t, errors.Is(ErrorItemNotFound, ErrNotFound))
+	assert.True(t, errors.Is(ErrorConditionFailed, ErrConditionFailed))
+	assert.True(t, errors.Is(&VersionConflictError{TableName: "users", Version: 1}, ErrConditionFailed))
+
+	var awsErr awserr.Error
+	conflict := &VersionConflictError{TableName: "users", Version: 1, Err: awserr.New("ConditionalCheckFailedException", "Message", nil)}
+	assert.True(t, errors.As(conflict, &awsErr))
+	assert.True(t, errors.Is(&MissingKeyError{AttributeName: "id", KeyType: KeyTypeHASH}, ErrValidation))
+	assert.True(t, errors.Is(&ValidationError{}, ErrValidation))
+	assert.True(t, errors.Is(&TransactionCanceledError{}, ErrTransactionCanceled))
+	assert.False(t, errors.Is(&ValidationError{}, ErrNotFound))
+}
+
+func TestTableErrors(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	_, err := tbl.Get("1")
+	assert.True(t, errors.Is(err, ErrTableNotFound))
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	assert.True(t, errors.Is(tbl.Create(), ErrTableExists))
+
+	_, err = tbl.Get("1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
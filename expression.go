@@ -0,0 +1,160 @@
+package dytona
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var ErrorExpressionValues error = errors.New("Expression placeholders and values count mismatch")
+
+// Helper for building DynamoDB expressions with attribute name and value placeholders,
+// shared between key condition, filter and projection expressions of a single request
+type expression struct {
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	err    error
+}
+
+func newExpression() *expression {
+	return &expression{
+		names:  make(map[string]*string),
+		values: make(map[string]*dynamodb.AttributeValue),
+	}
+}
+
+// Getting a placeholder for an attribute name, like `name` -> `#n0`,
+// the same attribute always gets the same placeholder
+func (e *expression) name(attributeName string) string {
+	for placeholder, name := range e.names {
+		if *name == attributeName {
+			return placeholder
+		}
+	}
+
+	placeholder := fmt.Sprintf("#n%d", len(e.names))
+	e.names[placeholder] = aws.String(attributeName)
+
+	return placeholder
+}
+
+// Getting a placeholder for a value, like `Roman` -> `:v0`
+func (e *expression) value(value interface{}) string {
+	av, err := dynamodbattribute.Marshal(value)
+	if err != nil {
+		e.err = err
+		return ""
+	}
+
+	return e.attributeValue(av)
+}
+
+// Getting a placeholder for an already encoded value
+func (e *expression) attributeValue(av *dynamodb.AttributeValue) string {
+	placeholder := fmt.Sprintf(":v%d", len(e.values))
+	e.values[placeholder] = av
+
+	return placeholder
+}
+
+// Replacing `#attribute` tokens with attribute name placeholders
+// and `?` tokens with value placeholders in order, e.g.
+//
+//	`#age > ? AND begins_with(#name, ?)`
+func (e *expression) parse(s string, values []interface{}) string {
+	var (
+		b strings.Builder
+		n int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '#':
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+
+			b.WriteString(e.name(s[i+1 : j]))
+			i = j - 1
+			break
+		case '?':
+			if n >= len(values) {
+				e.err = ErrorExpressionValues
+				return ""
+			}
+
+			b.WriteString(e.value(values[n]))
+			n++
+			break
+		default:
+			b.WriteByte(s[i])
+			break
+		}
+	}
+
+	if n != len(values) {
+		e.err = ErrorExpressionValues
+		return ""
+	}
+
+	return b.String()
+}
+
+// Getting attribute names of `#attribute` tokens in order of appearance
+func expressionNames(s string) []string {
+	var names []string
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '#' {
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && isNameChar(s[j]) {
+			j++
+		}
+
+		names = append(names, s[i+1:j])
+		i = j - 1
+	}
+
+	return names
+}
+
+// Building projection expression out of attribute names
+func (e *expression) projection(attributeNames []string) string {
+	var placeholders []string
+
+	for _, attributeName := range attributeNames {
+		placeholders = append(placeholders, e.name(attributeName))
+	}
+
+	return strings.Join(placeholders, ", ")
+}
+
+// Expression names map to be used in the request, nil if empty
+func (e *expression) attributeNames() map[string]*string {
+	if len(e.names) == 0 {
+		return nil
+	}
+
+	return e.names
+}
+
+// Expression values map to be used in the request, nil if empty
+func (e *expression) attributeValues() map[string]*dynamodb.AttributeValue {
+	if len(e.values) == 0 {
+		return nil
+	}
+
+	return e.values
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,82 @@
+package dytona
+
+// Optional interfaces an item type can implement to be called around writes and loads,
+// an error returned from a Before hook aborts the operation, e.g.
+//
+//	func (u *User) BeforeSave() error {
+//		u.Email = strings.ToLower(u.Email)
+//		return nil
+//	}
+
+// Called before the item is put or updated
+type BeforeSaver interface {
+	BeforeSave() error
+}
+
+// Called after the item was put or updated, an error returned from it comes as *AfterSaveError
+// since the item is already written by then
+type AfterSaver interface {
+	AfterSave() error
+}
+
+// Called before the item is soft-deleted or purged
+type BeforeDeleter interface {
+	BeforeDelete() error
+}
+
+// Called after the item was read from the table by Get, Query or Scan
+type AfterLoader interface {
+	AfterLoad() error
+}
+
+func beforeSave(item Itemer) error {
+	if h, ok := item.(BeforeSaver); ok {
+		return h.BeforeSave()
+	}
+
+	return nil
+}
+
+// Returned when the item was written but its AfterSave() hook has failed, the write is not undone, e.g.
+//
+//	var hookErr *dytona.AfterSaveError
+//	if errors.As(err, &hookErr) {
+//		// the item is saved anyway
+//	}
+type AfterSaveError struct {
+	Err error
+}
+
+func (e *AfterSaveError) Error() string {
+	return "Item was saved, but AfterSave failed: " + e.Err.Error()
+}
+
+func (e *AfterSaveError) Unwrap() error {
+	return e.Err
+}
+
+func afterSave(item Itemer) error {
+	if h, ok := item.(AfterSaver); ok {
+		if err := h.AfterSave(); err != nil {
+			return &AfterSaveError{Err: err}
+		}
+	}
+
+	return nil
+}
+
+func beforeDelete(item Itemer) error {
+	if h, ok := item.(BeforeDeleter); ok {
+		return h.BeforeDelete()
+	}
+
+	return nil
+}
+
+func afterLoad(item Itemer) error {
+	if h, ok := item.(AfterLoader); ok {
+		return h.AfterLoad()
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package dytona
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+var errorHookAborted error = errors.New("Aborted")
+
+type hookedUser struct {
+	Item  `json:"-" dynamodbav:"-"`
+	Email string `json:"email" dynamodbav:"email"`
+
+	calls []string
+	abort bool
+	fail  bool
+}
+
+func (u *hookedUser) BeforeSave() error {
+	u.calls = append(u.calls, "BeforeSave")
+	if u.abort {
+		return errorHookAborted
+	}
+
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *hookedUser) AfterSave() error {
+	u.calls = append(u.calls, "AfterSave")
+	if u.fail {
+		return errorHookAborted
+	}
+
+	return nil
+}
+
+func (u *hookedUser) BeforeDelete() error {
+	u.calls = append(u.calls, "BeforeDelete")
+	if u.abort {
+		return errorHookAborted
+	}
+
+	return nil
+}
+
+func (u *hookedUser) AfterLoad() error {
+	u.calls = append(u.calls, "AfterLoad")
+	return nil
+}
+
+func TestHooksAbort(t *testing.T) {
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &hookedUser{}
+	}).WithSession(d.session)
+
+	u := tbl.NewItem().(*hookedUser)
+	u.abort = true
+
+	assert.Equal(t, errorHookAborted, u.Save())
+	assert.Equal(t, "", u.Id, "Aborted save should not touch the item")
+	assert.Equal(t, errorHookAborted, u.Update())
+	assert.Equal(t, errorHookAborted, u.SoftDelete())
+	assert.False(t, u.Deleted)
+	assert.Equal(t, errorHookAborted, u.Purge())
+	assert.Equal(t, []string{"BeforeSave", "BeforeSave", "BeforeDelete", "BeforeDelete"}, u.calls)
+}
+
+func TestHooksAfterLoad(t *testing.T) {
+	tbl := NewTable("users", func() Itemer {
+		return &hookedUser{}
+	})
+
+	item, err := tbl.decodeItem(map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("1")},
+		"email": {S: aws.String("roman@example.com")},
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"AfterLoad"}, item.(*hookedUser).calls)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &hookedUser{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*hookedUser)
+	u.Id = "1"
+	u.Email = "Roman@Example.com"
+	assert.Nil(t, u.Save())
+	assert.Equal(t, "roman@example.com", u.Email)
+
+	u.Set("Email", "Boris@Example.com")
+	assert.Nil(t, u.Update())
+	assert.Nil(t, u.Purge())
+	assert.Equal(t, []string{"BeforeSave", "AfterSave", "BeforeSave", "AfterSave", "BeforeDelete"}, u.calls)
+
+	item, err := tbl.WithDeleted().Scan().All()
+	assert.Nil(t, err)
+	assert.Len(t, item, 0)
+}
+
+func TestHooksAfterSaveError(t *testing.T) {
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &hookedUser{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*hookedUser)
+	u.Id = "1"
+	u.fail = true
+
+	var hookErr *AfterSaveError
+	err := u.Save()
+	assert.True(t, errors.As(err, &hookErr))
+	assert.True(t, errors.Is(err, errorHookAborted))
+
+	_, err = tbl.Get("1")
+	assert.Nil(t, err, "Item should be saved despite the failed hook")
+}
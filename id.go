@@ -0,0 +1,90 @@
+package dytona
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	crockfordAlphabet string = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base62Alphabet    string = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// KSUID timestamps are seconds since 2014-05-13T16:53:20Z
+	ksuidEpoch int64 = 1400000000
+)
+
+// Strategy for generating an empty `Id` on write
+type IdFunc func() string
+
+var (
+	IdUUID  IdFunc = NewUUID
+	IdULID  IdFunc = NewULID
+	IdKSUID IdFunc = NewKSUID
+
+	DefaultIdFunc IdFunc = IdUUID
+)
+
+// Random (version 4) UUID, e.g. `0f8fad5b-d9cb-469f-a165-70867728950e`
+func NewUUID() string {
+	b := randomBytes(16)
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Lexicographically sortable ULID, e.g. `01BX5ZZKBKACTAV9WEVGEMMVRZ`
+func NewULID() string {
+	b := make([]byte, 16)
+
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	copy(b[6:], randomBytes(10))
+
+	return encode(b, crockfordAlphabet, 26)
+}
+
+// K-sortable KSUID, e.g. `0ujtsYcgvSTl8PAuAdqWYSMnLOv`
+func NewKSUID() string {
+	b := make([]byte, 20)
+
+	binary.BigEndian.PutUint32(b, uint32(time.Now().Unix()-ksuidEpoch))
+	copy(b[4:], randomBytes(16))
+
+	return encode(b, base62Alphabet, 27)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("dytona: can not read random bytes: " + err.Error())
+	}
+
+	return b
+}
+
+// Encoding bytes as a big-endian number in the alphabet's base, left padded to the length
+func encode(b []byte, alphabet string, length int) string {
+	var (
+		n    *big.Int = new(big.Int).SetBytes(b)
+		base *big.Int = big.NewInt(int64(len(alphabet)))
+		mod  *big.Int = new(big.Int)
+		out  []byte   = make([]byte, length)
+	)
+
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+
+	return string(out)
+}
@@ -0,0 +1,38 @@
+package dytona
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUUID(t *testing.T) {
+	id := NewUUID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	assert.NotEqual(t, id, NewUUID())
+}
+
+func TestNewULID(t *testing.T) {
+	id := NewULID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`), id)
+	assert.NotEqual(t, id, NewULID())
+}
+
+func TestNewKSUID(t *testing.T) {
+	id := NewKSUID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-Za-z]{27}$`), id)
+	assert.NotEqual(t, id, NewKSUID())
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "00000000000000000000000000", encode(make([]byte, 16), crockfordAlphabet, 26))
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encode([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}, crockfordAlphabet, 26))
+	assert.Equal(t, "aWgEPTl1tmebfsQzFP4bxwgy80V", encode([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}, base62Alphabet, 27))
+}
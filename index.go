@@ -0,0 +1,114 @@
+package dytona
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	ErrorIndexNotFound               error = errors.New("Index not found")
+	ErrorConsistentReadOnGlobalIndex error = errors.New("Global secondary indexes do not support consistent reads")
+)
+
+// Returned when querying an index for attributes it doesn't project, including the `Deleted`
+// one when soft-deleted items are excluded, WithDeleted() is needed to query such indexes
+type ProjectionError struct {
+	IndexName     string
+	AttributeName string
+}
+
+func (e *ProjectionError) Error() string {
+	return fmt.Sprintf("Attribute '%s' is not projected into index '%s'", e.AttributeName, e.IndexName)
+}
+
+// Local or global secondary index of a table
+type Index struct {
+	table      *Table
+	name       string
+	global     bool
+	keySchema  []*dynamodb.KeySchemaElement
+	projection *dynamodb.Projection
+	err        error
+}
+
+// Getting a secondary index by name, ErrorIndexNotFound is returned
+// on query when the table has no such index
+func (t *Table) Index(name string) *Index {
+	for _, lsi := range t.getDescription().LocalSecondaryIndexes {
+		if *lsi.IndexName == name {
+			return &Index{table: t, name: name, keySchema: lsi.KeySchema, projection: lsi.Projection}
+		}
+	}
+
+	for _, gsi := range t.getDescription().GlobalSecondaryIndexes {
+		if *gsi.IndexName == name {
+			return &Index{table: t, name: name, global: true, keySchema: gsi.KeySchema, projection: gsi.Projection}
+		}
+	}
+
+	return &Index{table: t, name: name, err: ErrorIndexNotFound}
+}
+
+func (i *Index) Name() string {
+	return i.name
+}
+
+func (i *Index) IsGlobal() bool {
+	return i.global
+}
+
+// Starting a query for items with the given index hash key value
+func (i *Index) Query(hashKey interface{}) *Query {
+	q := i.table.Query(hashKey)
+	q.index = i
+	return q
+}
+
+// Getting index hash and range key attribute names
+func (i *Index) keyNames() (hashKey, rangeKey string) {
+	for _, k := range i.keySchema {
+		switch *k.KeyType {
+		case KeyTypeHASH:
+			hashKey = *k.AttributeName
+			break
+		case KeyTypeRANGE:
+			rangeKey = *k.AttributeName
+			break
+		}
+	}
+
+	return
+}
+
+// Making sure all the attributes are projected into the index,
+// only global secondary indexes are checked since DynamoDB fetches
+// the rest of the attributes from the table for local ones
+func (i *Index) checkProjection(attributeNames []string) error {
+	if !i.global || i.projection == nil || *i.projection.ProjectionType == KeyProjectionTypeALL {
+		return nil
+	}
+
+	projected := make(map[string]bool)
+
+	for _, k := range i.keySchema {
+		projected[*k.AttributeName] = true
+	}
+
+	for _, k := range i.table.keySchema() {
+		projected[*k.AttributeName] = true
+	}
+
+	for _, attributeName := range i.projection.NonKeyAttributes {
+		projected[*attributeName] = true
+	}
+
+	for _, attributeName := range attributeNames {
+		if !projected[attributeName] {
+			return &ProjectionError{IndexName: i.name, AttributeName: attributeName}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package dytona
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string    `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date  time.Time `json:"time" dynamodbav:"time" dynamodbpk:"RANGE"`
+		Count int       `json:"count" dynamodbav:"count" dynamodblsi:"CountLsi,RANGE,0,0,KEYS_ONLY"`
+		Email string    `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,1,1,INCLUDE"`
+		Name  string    `json:"name" dynamodbav:"name" dynamodbgsi:"EmailGsi,INCLUDE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	{
+		idx := tbl.Index("CountLsi")
+		assert.Nil(t, idx.err)
+		assert.False(t, idx.IsGlobal())
+
+		input, err := idx.Query("1").RangeGt(5).Project("name").ConsistentRead().input()
+		assert.Nil(t, err)
+		assert.Equal(t, "CountLsi", *input.IndexName)
+		assert.Equal(t, "#n0 = :v0 AND #n1 > :v1", *input.KeyConditionExpression)
+		assert.Equal(t, "uuid", *input.ExpressionAttributeNames["#n0"])
+		assert.Equal(t, "count", *input.ExpressionAttributeNames["#n1"])
+	}
+
+	{
+		idx := tbl.Index("EmailGsi")
+		assert.Nil(t, idx.err)
+		assert.True(t, idx.IsGlobal())
+
+		_, err := idx.Query("roman@example.com").input()
+		assert.Equal(t, &ProjectionError{IndexName: "EmailGsi", AttributeName: "deleted"}, err, "Soft-deleted items can't be excluded")
+
+		input, err := idx.Query("roman@example.com").Project("uuid", "time", "email", "name").WithDeleted().input()
+		assert.Nil(t, err)
+		assert.Nil(t, input.FilterExpression)
+		assert.Equal(t, "EmailGsi", *input.IndexName)
+		assert.Equal(t, "#n0 = :v0", *input.KeyConditionExpression)
+		assert.Equal(t, "email", *input.ExpressionAttributeNames["#n0"])
+
+		_, err = idx.Query("roman@example.com").Project("count").WithDeleted().input()
+		assert.Equal(t, &ProjectionError{IndexName: "EmailGsi", AttributeName: "count"}, err)
+
+		_, err = idx.Query("roman@example.com").Filter("#name = ? AND #count > ?", "Roman", 1).WithDeleted().input()
+		assert.Equal(t, &ProjectionError{IndexName: "EmailGsi", AttributeName: "count"}, err)
+
+		_, err = idx.Query("roman@example.com").Filter("begins_with(#name, ?)", "R").WithDeleted().input()
+		assert.Nil(t, err)
+
+		_, err = idx.Query("roman@example.com").ConsistentRead().WithDeleted().input()
+		assert.Equal(t, ErrorConsistentReadOnGlobalIndex, err)
+
+		_, err = idx.Query("roman@example.com").RangeEq(1).WithDeleted().input()
+		assert.Equal(t, ErrorUnexpectedRangeKey, err)
+	}
+
+	{
+		_, err := tbl.Index("NotAnIndex").Query("1").input()
+		assert.Equal(t, ErrorIndexNotFound, err)
+	}
+}
+
+func TestIndexQuery(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,1,1,KEYS_ONLY"`
+		Name  string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+	u.Email = "roman@example.com"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	items, err := tbl.Index("EmailGsi").Query("roman@example.com").WithDeleted().All()
+	assert.Nil(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "1", items[0].(*User).Id)
+		assert.Equal(t, "roman@example.com", items[0].(*User).Email)
+		assert.Equal(t, "", items[0].(*User).Name, "Name is not projected")
+	}
+}
@@ -1,17 +1,63 @@
 package dytona
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/imdario/mergo"
 )
 
+var (
+	ErrorItemNotSet  error = errors.New("Item is not set, use SetItem() first")
+	ErrorNoSession   error = errors.New("Item has no DynamoDB session")
+	ErrorNoTableName error = errors.New("Item has no table name")
+	ErrorVersionType error = errors.New("Version field has to be an integer")
+	ErrorNoDeleted   error = errors.New("Item has no boolean Deleted field")
+)
+
+// Returned on write when one of the key schema attributes has no value
+type MissingKeyError struct {
+	AttributeName string
+	KeyType       string
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("Key attribute '%s' (%s) has no value", e.AttributeName, e.KeyType)
+}
+
+func (e *MissingKeyError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Returned on write when the stored item's version doesn't match the item's one,
+// the item has to be reloaded and the write retried, Err is the failed condition error
+type VersionConflictError struct {
+	TableName string
+	Version   int64
+	Err       error
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("Item in table '%s' was modified, expected version %d", e.TableName, e.Version)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConditionFailed
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
 type Itemer interface {
 	// GetId() bson.ObjectId
 	// SetId(bson.ObjectId)
@@ -30,35 +76,62 @@ type Itemer interface {
 	// SetConnection(*Connection)
 	WithTableName(tableName string) Itemer
 	WithSession(session *dynamodb.DynamoDB) Itemer
+	WithIdFunc(idFunc IdFunc) Itemer
 
 	GetItem() Itemer
 	SetItem(item Itemer) Itemer
 
+	Marshal() (map[string]*dynamodb.AttributeValue, error)
+	Unmarshal(av map[string]*dynamodb.AttributeValue) error
+
 	Get(field string) (interface{}, error)
 	Set(field string, value interface{}) bool
 
+	ChangedAttributes() []string
+
 	Save() error
+	SaveWithContext(ctx context.Context) error
+	Update() error
+	UpdateWithContext(ctx context.Context) error
+	UpdateIf(condition string, values ...interface{}) error
+	UpdateIfWithContext(ctx context.Context, condition string, values ...interface{}) error
+
+	SoftDelete() error
+	SoftDeleteWithContext(ctx context.Context) error
+	Restore() error
+	RestoreWithContext(ctx context.Context) error
+	Purge() error
+	PurgeWithContext(ctx context.Context) error
 
 	get(field string) (rValue reflect.Value, tag string, found bool)
-	// Update(interface{}) (error, map[string]interface{})
-	// Validate(...interface{}) (bool, []error)
-	// DefaultValidate() (bool, []error)
+	base() *Item
+
+	Validate() error
+	DefaultValidate() error
 }
 
 type Item struct {
 	item      Itemer             `json:"-" bson:"-"`
 	tableName string             `json:"-" bson:"-"`
 	session   *dynamodb.DynamoDB `json:"-" bson:"-"`
+	idFunc    IdFunc             `json:"-" bson:"-"`
 
-	Id        string    `json:"id" dynamodbav:"id"`
-	CreatedAt time.Time `json:"created_at" dynamodbav:"c_at"`
-	UpdatedAt time.Time `json:"updated_at" dynamodbav:"u_at"`
-	Deleted   bool      `json:"deleted" dynamodbav:"deleted,omitempty"`
+	// attributes changed through Set() and the item's state since load or last write
+	dirty  map[string]bool                     `json:"-" bson:"-"`
+	loaded map[string]*dynamodb.AttributeValue `json:"-" bson:"-"`
+
+	Id        string     `json:"id" dynamodbav:"id"`
+	CreatedAt time.Time  `json:"created_at" dynamodbav:"c_at"`
+	UpdatedAt time.Time  `json:"updated_at" dynamodbav:"u_at"`
+	Deleted   bool       `json:"deleted" dynamodbav:"deleted,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" dynamodbav:"d_at,omitempty"`
 }
 
 // Applying Itemer interface
 var _ Itemer = (*Item)(nil)
 
+var timeType reflect.Type = reflect.TypeOf(time.Time{})
+
 // Getting a filed reflect value by string name
 func (i *Item) get(field string) (rValue reflect.Value, tag string, found bool) {
 	rValue = reflect.ValueOf(i.item).Elem().FieldByName(field)
@@ -69,6 +142,11 @@ func (i *Item) get(field string) (rValue reflect.Value, tag string, found bool)
 	return rValue, tag, found
 }
 
+// Getting the embedded Item itself, whatever type it is embedded into
+func (i *Item) base() *Item {
+	return i
+}
+
 func (i *Item) GetItem() Itemer {
 	return i.item
 }
@@ -88,6 +166,12 @@ func (i *Item) WithSession(session *dynamodb.DynamoDB) Itemer {
 	return i.item
 }
 
+// Setting the strategy for generating an empty `Id` on Save()
+func (i *Item) WithIdFunc(idFunc IdFunc) Itemer {
+	i.idFunc = idFunc
+	return i.item
+}
+
 func (i *Item) Marshal() (map[string]*dynamodb.AttributeValue, error) {
 	e := dynamodbattribute.NewEncoder(func(e *dynamodbattribute.Encoder) {
 		e.NullEmptyString = false
@@ -115,6 +199,120 @@ func (i *Item) Marshal() (map[string]*dynamodb.AttributeValue, error) {
 	return av.M, nil
 }
 
+// Inverse of Marshal(), filling both the base Item fields and the outer item's fields.
+// Base fields overridden in the outer struct, like `Id int dynamodbav:"_id"`, are left to the outer one.
+func (i *Item) Unmarshal(av map[string]*dynamodb.AttributeValue) error {
+	if i.item == nil {
+		return ErrorItemNotSet
+	}
+
+	var (
+		item      Itemer             = i.item
+		session   *dynamodb.DynamoDB = i.session
+		tableName string             = i.tableName
+		rValue    reflect.Value      = reflect.ValueOf(i).Elem()
+		outerType reflect.Type       = reflect.TypeOf(i.item).Elem()
+	)
+
+	for n := 0; n < rValue.NumField(); n++ {
+		f := rValue.Type().Field(n)
+
+		// skipping private link fields
+		if f.PkgPath != "" {
+			continue
+		}
+
+		// skipping fields declared on the outer struct itself
+		if of, ok := outerType.FieldByName(f.Name); ok && len(of.Index) == 1 {
+			continue
+		}
+
+		attributeName := strings.Split(f.Tag.Get(TagAttributeValue), ",")[0]
+		if v, ok := av[attributeName]; ok {
+			if err := dynamodbattribute.Unmarshal(v, rValue.Field(n).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := dynamodbattribute.UnmarshalMap(av, i.item); err != nil {
+		return err
+	}
+
+	// relinking the item in case the outer struct decoding touched the embedded Item
+	i.item = item
+	i.session = session
+	i.tableName = tableName
+
+	i.resetChanges(av)
+
+	return nil
+}
+
+// Getting attribute names changed through Set() or since the item was loaded or written.
+// Only the attributes known at load time are compared, so partially loaded items
+// don't overwrite the attributes they were loaded without.
+func (i *Item) ChangedAttributes() []string {
+	var names []string
+
+	if i.item == nil {
+		return names
+	}
+
+	av, err := i.Marshal()
+	if err != nil {
+		return names
+	}
+
+	set, remove := i.changes(av)
+	for name := range set {
+		names = append(names, name)
+	}
+	names = append(names, remove...)
+	sort.Strings(names)
+
+	return names
+}
+
+// Splitting changed attributes into the ones to be set and removed
+func (i *Item) changes(av map[string]*dynamodb.AttributeValue) (set map[string]*dynamodb.AttributeValue, remove []string) {
+	set = make(map[string]*dynamodb.AttributeValue)
+
+	for name := range i.dirty {
+		if v, ok := av[name]; ok {
+			set[name] = v
+		} else {
+			remove = append(remove, name)
+		}
+	}
+
+	for name, loaded := range i.loaded {
+		if i.dirty[name] {
+			continue
+		}
+
+		if v, ok := av[name]; !ok {
+			remove = append(remove, name)
+		} else if !reflect.DeepEqual(v, loaded) {
+			set[name] = v
+		}
+	}
+
+	sort.Strings(remove)
+
+	return set, remove
+}
+
+// Remembering the attributes' state, so only later changes are tracked
+func (i *Item) resetChanges(av map[string]*dynamodb.AttributeValue) {
+	i.dirty = nil
+	i.loaded = make(map[string]*dynamodb.AttributeValue, len(av))
+
+	for name, v := range av {
+		i.loaded[name] = v
+	}
+}
+
 func (i *Item) Get(field string) (interface{}, error) {
 	if rValue, _, found := i.get(field); !found {
 		return nil, errors.New(fmt.Sprintf("Field with name '%s' not found", field))
@@ -140,17 +338,626 @@ func (i *Item) Set(field string, value interface{}) bool {
 	rValue.Set(reflect.ValueOf(value))
 
 	// Checking if value was actually set
-	if rValue, _, _ := i.get(field); rValue.Interface() == value {
+	if rValue, tag, _ := i.get(field); rValue.Interface() == value {
+		if tag != "" && tag != "-" {
+			if i.dirty == nil {
+				i.dirty = make(map[string]bool)
+			}
+			i.dirty[tag] = true
+		}
+
 		return true
 	}
 
 	return false
 }
 
+// Putting the whole item to the table it was bound to, key attributes are
+// checked against the key schema derived from the `dynamodbpk` tags
 func (i *Item) Save() error {
-	// reflectStruct := reflect.ValueOf(d.item).Elem()
+	return i.SaveWithContext(context.Background())
+}
 
-	fmt.Printf("reflectStruct: %#v\n", i)
+// Same as Save() bound to the context
+func (i *Item) SaveWithContext(ctx context.Context) error {
+	if err := i.checkBinding(); err != nil {
+		return err
+	}
+
+	if err := beforeSave(i.item); err != nil {
+		return err
+	}
+
+	if err := i.validate(); err != nil {
+		return err
+	}
+
+	revert := i.touch(time.Now().UTC())
+
+	input, versionValue, versioned, err := i.putInput()
+	if err != nil {
+		revert()
+		return err
+	}
+
+	if _, err = i.session.PutItemWithContext(ctx, input); err != nil {
+		revert()
+
+		if versioned && isConditionalCheckFailed(err) {
+			return &VersionConflictError{TableName: i.tableName, Version: versionValue.Int(), Err: err}
+		}
+
+		return classify(err)
+	}
+
+	if versioned {
+		versionValue.SetInt(versionValue.Int() + 1)
+	}
+
+	i.resetChanges(input.Item)
+
+	return afterSave(i.item)
+}
+
+// Building a put request of the whole item, the version attribute is
+// incremented and checked against the stored one
+func (i *Item) putInput() (input *dynamodb.PutItemInput, versionValue reflect.Value, versioned bool, err error) {
+	av, err := i.Marshal()
+	if err != nil {
+		return nil, versionValue, false, err
+	}
+
+	if _, err := i.key(av); err != nil {
+		return nil, versionValue, false, err
+	}
+
+	input = &dynamodb.PutItemInput{
+		TableName: aws.String(i.tableName),
+		Item:      av,
+	}
+
+	versionValue, versionName, versioned, err := i.versionField()
+	if err != nil {
+		return nil, versionValue, false, err
+	}
+
+	if versioned {
+		e := newExpression()
+
+		av[versionName] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(versionValue.Int()+1, 10))}
+		input.ConditionExpression = aws.String(versionCondition(e, versionName, versionValue.Int()))
+		input.ExpressionAttributeNames = e.attributeNames()
+		input.ExpressionAttributeValues = e.attributeValues()
+	}
+
+	return input, versionValue, versioned, nil
+}
+
+// Updating only the changed attributes of an existing item,
+// the item gets the new state returned by DynamoDB
+func (i *Item) Update() error {
+	return i.UpdateWithContext(context.Background())
+}
+
+// Same as Update() bound to the context
+func (i *Item) UpdateWithContext(ctx context.Context) error {
+	return i.UpdateIfWithContext(ctx, "")
+}
+
+// Updating only the changed attributes of an existing item if the condition is met,
+// `#attribute` are attribute names and `?` are values in order, e.g.
+//
+//	UpdateIf("#count < ?", 10)
+func (i *Item) UpdateIf(condition string, values ...interface{}) error {
+	return i.UpdateIfWithContext(context.Background(), condition, values...)
+}
+
+// Same as UpdateIf() bound to the context
+func (i *Item) UpdateIfWithContext(ctx context.Context, condition string, values ...interface{}) error {
+	if err := i.checkBinding(); err != nil {
+		return err
+	}
+
+	if err := beforeSave(i.item); err != nil {
+		return err
+	}
+
+	if err := i.validate(); err != nil {
+		return err
+	}
+
+	if err := i.updateIf(ctx, condition, values); err != nil {
+		return err
+	}
+
+	return afterSave(i.item)
+}
+
+func (i *Item) updateIf(ctx context.Context, condition string, values []interface{}) error {
+	input, err := i.updateInput(condition, values, time.Now().UTC())
+	if err != nil || input == nil {
+		return err
+	}
+
+	out, err := i.session.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			versionValue, _, versioned, _ := i.versionField()
+
+			switch {
+			case versioned && condition == "":
+				// the version condition can't be told apart from the existence one
+				return &VersionConflictError{TableName: i.tableName, Version: versionValue.Int(), Err: err}
+			case versioned:
+				// the condition is checked along with the version one
+				if err := i.versionConflict(ctx, i.session, err); err != nil {
+					return err
+				}
+			default:
+				if condition == "" {
+					return ErrorItemNotFound
+				}
+			}
+		}
+
+		return classify(err)
+	}
+
+	return i.Unmarshal(out.Attributes)
+}
+
+// Marking an existing item as deleted, soft-deleted items are excluded from
+// Get, Query and Scan results unless WithDeleted() is used
+func (i *Item) SoftDelete() error {
+	return i.SoftDeleteWithContext(context.Background())
+}
+
+// Same as SoftDelete() bound to the context
+func (i *Item) SoftDeleteWithContext(ctx context.Context) error {
+	if err := i.checkBinding(); err != nil {
+		return err
+	}
+
+	if err := beforeDelete(i.item); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return i.setDeleted(ctx, true, &now, "attribute_not_exists(#%s) OR #%s = ?", false)
+}
+
+// Bringing a soft-deleted item back, it is treated as a save by the hooks
+func (i *Item) Restore() error {
+	return i.RestoreWithContext(context.Background())
+}
+
+// Same as Restore() bound to the context
+func (i *Item) RestoreWithContext(ctx context.Context) error {
+	if err := i.checkBinding(); err != nil {
+		return err
+	}
+
+	if err := beforeSave(i.item); err != nil {
+		return err
+	}
+
+	if err := i.setDeleted(ctx, false, nil, "#%s = ?", true); err != nil {
+		return err
+	}
+
+	return afterSave(i.item)
+}
+
+// Updating `Deleted` and `DeletedAt` only if the stored item is in the expected state,
+// the fields are reverted when the update fails
+func (i *Item) setDeleted(ctx context.Context, deleted bool, deletedAt *time.Time, condition string, expected bool) error {
+	rValue, tag, found := i.get("Deleted")
+	if !found || rValue.Kind() != reflect.Bool || tag == "" || tag == "-" {
+		return ErrorNoDeleted
+	}
+
+	previous := rValue.Bool()
+	i.Set("Deleted", deleted)
+
+	var previousAt interface{}
+	if atValue, _, found := i.get("DeletedAt"); found && atValue.Type() == reflect.TypeOf(deletedAt) {
+		previousAt = atValue.Interface()
+		i.Set("DeletedAt", deletedAt)
+	}
+
+	err := i.updateIf(ctx, fmt.Sprintf(condition, tag, tag), []interface{}{expected})
+	if err != nil {
+		rValue.SetBool(previous)
+		if previousAt != nil {
+			atValue, _, _ := i.get("DeletedAt")
+			atValue.Set(reflect.ValueOf(previousAt))
+		}
+
+		var conflict *VersionConflictError
+		if isConditionalCheckFailed(err) && !errors.As(err, &conflict) {
+			return ErrorItemNotFound
+		}
+	}
+
+	return err
+}
+
+// Reading the stored version of a versioned item after a failed condition,
+// VersionConflictError is returned when it differs from the item's one
+func (i *Item) versionConflict(ctx context.Context, session *dynamodb.DynamoDB, cause error) error {
+	versionValue, versionName, versioned, err := i.versionField()
+	if err != nil || !versioned {
+		return err
+	}
+
+	av, err := i.Marshal()
+	if err != nil {
+		return err
+	}
+
+	key, err := i.key(av)
+	if err != nil {
+		return err
+	}
+
+	e := newExpression()
+	out, err := session.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:                aws.String(i.tableName),
+		Key:                      key,
+		ConsistentRead:           aws.Bool(true),
+		ProjectionExpression:     aws.String(e.projection([]string{versionName})),
+		ExpressionAttributeNames: e.attributeNames(),
+	})
+	if err != nil {
+		return classify(err)
+	}
+
+	if len(out.Item) == 0 {
+		return nil
+	}
+
+	var stored int64
+	if v := out.Item[versionName]; v != nil && v.N != nil {
+		if stored, err = strconv.ParseInt(*v.N, 10, 64); err != nil {
+			return err
+		}
+	}
+
+	if stored == versionValue.Int() {
+		return nil
+	}
+
+	return &VersionConflictError{TableName: i.tableName, Version: versionValue.Int(), Err: cause}
+}
+
+// Deleting the item from the table for good
+func (i *Item) Purge() error {
+	return i.PurgeWithContext(context.Background())
+}
+
+// Same as Purge() bound to the context
+func (i *Item) PurgeWithContext(ctx context.Context) error {
+	if err := i.checkBinding(); err != nil {
+		return err
+	}
+
+	if err := beforeDelete(i.item); err != nil {
+		return err
+	}
+
+	input, versionValue, versioned, err := i.deleteInput()
+	if err != nil {
+		return err
+	}
+
+	if _, err = i.session.DeleteItemWithContext(ctx, input); err != nil {
+		if versioned && isConditionalCheckFailed(err) {
+			return &VersionConflictError{TableName: i.tableName, Version: versionValue.Int(), Err: err}
+		}
+
+		return classify(err)
+	}
+
+	i.resetChanges(nil)
+
+	return nil
+}
+
+// Building a delete request by the item's key, checking the version if the item has one
+func (i *Item) deleteInput() (input *dynamodb.DeleteItemInput, versionValue reflect.Value, versioned bool, err error) {
+	av, err := i.Marshal()
+	if err != nil {
+		return nil, versionValue, false, err
+	}
+
+	key, err := i.key(av)
+	if err != nil {
+		return nil, versionValue, false, err
+	}
+
+	input = &dynamodb.DeleteItemInput{
+		TableName: aws.String(i.tableName),
+		Key:       key,
+	}
+
+	versionValue, versionName, versioned, err := i.versionField()
+	if err != nil {
+		return nil, versionValue, false, err
+	}
+
+	if versioned {
+		e := newExpression()
+
+		input.ConditionExpression = aws.String(versionCondition(e, versionName, versionValue.Int()))
+		input.ExpressionAttributeNames = e.attributeNames()
+		input.ExpressionAttributeValues = e.attributeValues()
+	}
+
+	return input, versionValue, versioned, nil
+}
+
+// Building minimal SET/REMOVE update request for the changed attributes,
+// nil input is returned when there is nothing to update
+func (i *Item) updateInput(condition string, values []interface{}, now time.Time) (*dynamodb.UpdateItemInput, error) {
+	var (
+		e          *expression = newExpression()
+		setNames   []string
+		clauses    []string
+		update     []string
+		conditions []string
+	)
+
+	av, err := i.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := i.key(av)
+	if err != nil {
+		return nil, err
+	}
+
+	versionValue, versionName, versioned, err := i.versionField()
+	if err != nil {
+		return nil, err
+	}
+
+	set, remove := i.changes(av)
+	for name := range key {
+		delete(set, name)
+	}
+
+	// version is always incremented by the update itself
+	if versioned {
+		delete(set, versionName)
+		for n, name := range remove {
+			if name == versionName {
+				remove = append(remove[:n], remove[n+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(set) == 0 && len(remove) == 0 {
+		return nil, nil
+	}
+
+	// stamping the update time without touching the item, the new state is returned by DynamoDB
+	if rValue, tag, found := i.get("UpdatedAt"); found && rValue.Type() == timeType && tag != "" && tag != "-" {
+		if set[tag], err = dynamodbattribute.Marshal(now); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range set {
+		setNames = append(setNames, name)
+	}
+	sort.Strings(setNames)
+
+	for _, name := range setNames {
+		clauses = append(clauses, fmt.Sprintf("%s = %s", e.name(name), e.attributeValue(set[name])))
+	}
+	if versioned {
+		clauses = append(clauses, fmt.Sprintf("%s = %s", e.name(versionName), e.value(versionValue.Int()+1)))
+	}
+	if len(clauses) > 0 {
+		update = append(update, "SET "+strings.Join(clauses, ", "))
+	}
+
+	clauses = nil
+	for _, name := range remove {
+		clauses = append(clauses, e.name(name))
+	}
+	if len(clauses) > 0 {
+		update = append(update, "REMOVE "+strings.Join(clauses, ", "))
+	}
+
+	// updating existing items only
+	for _, k := range getKeySchema(reflect.TypeOf(i.item).Elem()) {
+		if *k.KeyType == KeyTypeHASH {
+			conditions = append(conditions, fmt.Sprintf("attribute_exists(%s)", e.name(*k.AttributeName)))
+		}
+	}
+
+	if versioned {
+		conditions = append(conditions, versionCondition(e, versionName, versionValue.Int()))
+	}
+
+	if condition != "" {
+		conditions = append(conditions, "("+e.parse(condition, values)+")")
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(i.tableName),
+		Key:                       key,
+		UpdateExpression:          aws.String(strings.Join(update, " ")),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  e.attributeNames(),
+		ExpressionAttributeValues: e.attributeValues(),
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	}, nil
+}
+
+// Generating an empty `Id` and stamping `CreatedAt` on first write and `UpdatedAt` on every write,
+// the returned function reverts the fields and is to be called when the write fails
+func (i *Item) touch(now time.Time) (revert func()) {
+	var previous []func()
+
+	if rValue, _, found := i.get("Id"); found && rValue.Kind() == reflect.String && rValue.String() == "" {
+		idFunc := i.idFunc
+		if idFunc == nil {
+			idFunc = DefaultIdFunc
+		}
+
+		rValue.SetString(idFunc())
+		previous = append(previous, func() { rValue.SetString("") })
+	}
+
+	if rValue, _, found := i.get("CreatedAt"); found && rValue.Type() == timeType && rValue.Interface().(time.Time).IsZero() {
+		rValue.Set(reflect.ValueOf(now))
+		previous = append(previous, func() { rValue.Set(reflect.ValueOf(time.Time{})) })
+	}
+
+	if rValue, _, found := i.get("UpdatedAt"); found && rValue.Type() == timeType {
+		updatedAt := rValue.Interface()
+		rValue.Set(reflect.ValueOf(now))
+		previous = append(previous, func() { rValue.Set(reflect.ValueOf(updatedAt)) })
+	}
+
+	return func() {
+		for _, f := range previous {
+			f()
+		}
+	}
+}
+
+// Capturing `Id`, `CreatedAt` and `UpdatedAt` to be set back to the current values later
+func (i *Item) stamps() (restore func()) {
+	var restores []func()
+
+	for _, field := range []string{"Id", "CreatedAt", "UpdatedAt"} {
+		if rValue, _, found := i.get(field); found {
+			value := reflect.New(rValue.Type()).Elem()
+			value.Set(rValue)
+			restores = append(restores, func() { rValue.Set(value) })
+		}
+	}
+
+	return func() {
+		for _, f := range restores {
+			f()
+		}
+	}
+}
+
+// Copying the item's fields and changes to be restored when it's not written after all
+func (i *Item) snapshot() (restore func()) {
+	var (
+		rValue reflect.Value = reflect.ValueOf(i.item).Elem()
+		saved  reflect.Value = reflect.New(rValue.Type()).Elem()
+		dirty  map[string]bool
+	)
+
+	saved.Set(rValue)
+	if i.dirty != nil {
+		dirty = make(map[string]bool, len(i.dirty))
+		for name := range i.dirty {
+			dirty[name] = true
+		}
+	}
+
+	return func() {
+		rValue.Set(saved)
+		i.dirty = dirty
+	}
+}
+
+// Getting the integer field tagged with `dynamodbversion`, used for optimistic locking
+func (i *Item) versionField() (rValue reflect.Value, attributeName string, found bool, err error) {
+	tp := reflect.TypeOf(i.item).Elem()
+
+	for n := 0; n < tp.NumField(); n++ {
+		f := tp.Field(n)
+
+		if _, ok := f.Tag.Lookup(TagVersion); !ok {
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			break
+		default:
+			return rValue, "", false, ErrorVersionType
+		}
+
+		attributeName = strings.Split(f.Tag.Get(TagAttributeValue), ",")[0]
+		if attributeName == "" || attributeName == "-" {
+			attributeName = f.Name
+		}
+
+		return reflect.ValueOf(i.item).Elem().Field(n), attributeName, true, nil
+	}
+
+	return rValue, "", false, nil
+}
+
+// Items with no version yet are expected to have no version attribute stored
+func versionCondition(e *expression, attributeName string, version int64) string {
+	if version == 0 {
+		return fmt.Sprintf("attribute_not_exists(%s)", e.name(attributeName))
+	}
+
+	return fmt.Sprintf("%s = %s", e.name(attributeName), e.value(version))
+}
+
+// Getting key attributes of the item according to its key schema
+func (i *Item) key(av map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	key := make(map[string]*dynamodb.AttributeValue)
+
+	for _, k := range getKeySchema(reflect.TypeOf(i.item).Elem()) {
+		if isEmptyKeyValue(av[*k.AttributeName]) {
+			return nil, &MissingKeyError{AttributeName: *k.AttributeName, KeyType: *k.KeyType}
+		}
+
+		key[*k.AttributeName] = av[*k.AttributeName]
+	}
+
+	return key, nil
+}
+
+// Making sure the item is linked to itself, a session and a table
+func (i *Item) checkBinding() error {
+	if i.item == nil {
+		return ErrorItemNotSet
+	}
+
+	if i.session == nil {
+		return ErrorNoSession
+	}
+
+	if i.tableName == "" {
+		return ErrorNoTableName
+	}
 
 	return nil
 }
+
+// DynamoDB does not accept empty or NULL values for key attributes
+func isEmptyKeyValue(av *dynamodb.AttributeValue) bool {
+	switch {
+	case av == nil:
+		return true
+	case av.S != nil:
+		return *av.S == ""
+	case av.N != nil:
+		return *av.N == ""
+	case av.B != nil:
+		return len(av.B) == 0
+	}
+
+	return true
+}
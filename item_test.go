@@ -1,9 +1,13 @@
 package dytona
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -124,3 +128,568 @@ func TestMarshalOverwriteWithIdField(t *testing.T) {
 	assert.Equal(t, "0001-01-01T00:00:00Z", *m["u_at"].S)
 
 }
+
+func TestSaveNotBound(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	u := &User{Name: "Roman"}
+	assert.Equal(t, ErrorItemNotSet, u.Save())
+
+	u.SetItem(u)
+	assert.Equal(t, ErrorNoSession, u.Save())
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	u.WithSession(d.session)
+	assert.Equal(t, ErrorNoTableName, u.Save())
+}
+
+func TestSaveMissingKey(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	u := tbl.NewItem().(*User)
+	assert.Equal(t, &MissingKeyError{AttributeName: "uuid", KeyType: KeyTypeHASH}, u.Save())
+
+	// Fields stamped for the failed write are reverted
+	assert.Equal(t, "", u.Id)
+	assert.True(t, u.CreatedAt.IsZero())
+	assert.True(t, u.UpdatedAt.IsZero())
+}
+
+func TestSave(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.UUID = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	out, err := d.session.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("users"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"uuid": {S: aws.String("1")},
+		},
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, out.Item["name"]) {
+		assert.Equal(t, "Roman", *out.Item["name"].S)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+	u := &User{}
+	u.SetItem(u).WithTableName("users")
+
+	err := u.Unmarshal(map[string]*dynamodb.AttributeValue{
+		"id":      {S: aws.String("1")},
+		"c_at":    {S: aws.String("2017-01-01T00:00:00Z")},
+		"deleted": {BOOL: aws.Bool(true)},
+		"name":    {S: aws.String("Roman")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", u.Id)
+	assert.Equal(t, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), u.CreatedAt)
+	assert.True(t, u.Deleted)
+	assert.Equal(t, "Roman", u.Name)
+	assert.Equal(t, u, u.GetItem())
+	assert.Equal(t, "users", u.tableName)
+}
+
+func TestUnmarshalOverwriteWithIdField(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Id   int    `json:"id" dynamodbav:"id"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+	u := &User{Id: 1, Name: "Roman"}
+	u.SetItem(u)
+
+	m, err := u.Marshal()
+	assert.Nil(t, err)
+
+	v := &User{}
+	v.SetItem(v)
+	assert.Nil(t, v.Unmarshal(m))
+	assert.Equal(t, 1, v.Id)
+	assert.Equal(t, "", v.Item.Id)
+	assert.Equal(t, "Roman", v.Name)
+}
+
+func TestUnmarshalOverwriteTag(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Id   int `json:"_id" dynamodbav:"_id"`
+	}
+	u := &User{}
+	u.SetItem(u)
+
+	err := u.Unmarshal(map[string]*dynamodb.AttributeValue{
+		"_id": {N: aws.String("2")},
+		"id":  {S: aws.String("1")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, u.Id)
+	assert.Equal(t, "", u.Item.Id)
+}
+
+func TestUnmarshalNotSet(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+	u := &User{}
+
+	assert.Equal(t, ErrorItemNotSet, u.Unmarshal(map[string]*dynamodb.AttributeValue{}))
+}
+
+func TestChangedAttributes(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Name  string `json:"name" dynamodbav:"name"`
+		Email string `json:"email" dynamodbav:"email,omitempty"`
+		Age   int    `json:"age" dynamodbav:"age"`
+	}
+	u := &User{}
+	u.SetItem(u)
+
+	assert.Nil(t, u.Unmarshal(map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("1")},
+		"name":  {S: aws.String("Roman")},
+		"email": {S: aws.String("roman@example.com")},
+	}))
+	assert.Empty(t, u.ChangedAttributes())
+
+	assert.True(t, u.Set("Age", 30))
+	u.Name = "Boris"
+	u.Email = ""
+
+	assert.Equal(t, []string{"age", "email", "name"}, u.ChangedAttributes())
+}
+
+func TestUpdateInput(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Name  string `json:"name" dynamodbav:"name"`
+		Email string `json:"email" dynamodbav:"email,omitempty"`
+		Age   int    `json:"age" dynamodbav:"age"`
+	}
+	u := &User{}
+	u.SetItem(u).WithTableName("users")
+
+	assert.Nil(t, u.Unmarshal(map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("1")},
+		"name":  {S: aws.String("Roman")},
+		"email": {S: aws.String("roman@example.com")},
+		"age":   {N: aws.String("30")},
+	}))
+
+	{
+		input, err := u.updateInput("", nil, time.Now().UTC())
+		assert.Nil(t, err)
+		assert.Nil(t, input, "Nothing to update")
+	}
+
+	u.Set("Name", "Boris")
+	u.Email = ""
+
+	input, err := u.updateInput("#age > ?", []interface{}{18}, time.Now().UTC())
+	assert.Nil(t, err)
+	assert.Equal(t, "users", *input.TableName)
+	assert.Equal(t, map[string]*dynamodb.AttributeValue{"id": {S: aws.String("1")}}, input.Key)
+	assert.Equal(t, "SET #n0 = :v0, #n1 = :v1 REMOVE #n2", *input.UpdateExpression)
+	assert.Equal(t, "attribute_exists(#n3) AND (#n4 > :v2)", *input.ConditionExpression)
+	assert.Equal(t, map[string]*string{
+		"#n0": aws.String("name"),
+		"#n1": aws.String("u_at"),
+		"#n2": aws.String("email"),
+		"#n3": aws.String("id"),
+		"#n4": aws.String("age"),
+	}, input.ExpressionAttributeNames)
+	assert.Equal(t, &dynamodb.AttributeValue{S: aws.String("Boris")}, input.ExpressionAttributeValues[":v0"])
+	assert.NotNil(t, input.ExpressionAttributeValues[":v1"].S, "Update time should be stamped")
+	assert.Equal(t, &dynamodb.AttributeValue{N: aws.String("18")}, input.ExpressionAttributeValues[":v2"])
+	assert.Equal(t, dynamodb.ReturnValueAllNew, *input.ReturnValues)
+}
+
+func TestUpdate(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+		Age  int    `json:"age" dynamodbav:"age"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+	u.Name = "Roman"
+	u.Age = 30
+	assert.Nil(t, u.Save())
+
+	u.Set("Age", 31)
+	assert.Nil(t, u.Update())
+	assert.Empty(t, u.ChangedAttributes())
+
+	item, err := tbl.Get("1")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 31, item.(*User).Age)
+		assert.Equal(t, "Roman", item.(*User).Name)
+	}
+
+	v := tbl.NewItem().(*User)
+	v.Id = "2"
+	v.Set("Age", 1)
+	assert.Equal(t, ErrorItemNotFound, v.Update())
+}
+
+func TestUpdateInputVersioned(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Version int64  `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+	u := &User{}
+	u.SetItem(u).WithTableName("users")
+
+	assert.Nil(t, u.Unmarshal(map[string]*dynamodb.AttributeValue{
+		"id":      {S: aws.String("1")},
+		"name":    {S: aws.String("Roman")},
+		"version": {N: aws.String("3")},
+	}))
+	u.Set("Name", "Boris")
+
+	input, err := u.updateInput("", nil, time.Now().UTC())
+	assert.Nil(t, err)
+	assert.Equal(t, "SET #n0 = :v0, #n1 = :v1, #n2 = :v2", *input.UpdateExpression)
+	assert.Equal(t, "attribute_exists(#n3) AND #n2 = :v3", *input.ConditionExpression)
+	assert.Equal(t, "version", *input.ExpressionAttributeNames["#n2"])
+	assert.Equal(t, "4", *input.ExpressionAttributeValues[":v2"].N)
+	assert.Equal(t, "3", *input.ExpressionAttributeValues[":v3"].N)
+}
+
+func TestVersionFieldType(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Version string `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+	u := &User{}
+	u.SetItem(u)
+
+	_, _, _, err := u.versionField()
+	assert.Equal(t, ErrorVersionType, err)
+}
+
+func TestSaveVersionConflict(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Version int    `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+	assert.Equal(t, 1, u.Version)
+
+	// concurrent writer holding the same version
+	v := tbl.NewItem().(*User)
+	v.Id = "1"
+	v.Version = 1
+
+	u.Name = "Boris"
+	assert.Nil(t, u.Save())
+	assert.Equal(t, 2, u.Version)
+
+	v.Name = "Ivan"
+	assertVersionConflict(t, v.Save(), 1)
+
+	u.Set("Name", "Gleb")
+	assert.Nil(t, u.Update())
+	assert.Equal(t, 3, u.Version)
+
+	v.Set("Name", "Ivan")
+	assertVersionConflict(t, v.Update(), 1)
+	assertVersionConflict(t, v.UpdateIf("#name = ?", "Gleb"), 1)
+
+	// own condition failing on the up-to-date item
+	u.Set("Name", "Ivan")
+	err := u.UpdateIf("#name = ?", "Roman")
+	assert.True(t, errors.Is(err, ErrConditionFailed))
+	assert.False(t, errors.As(err, new(*VersionConflictError)))
+}
+
+func assertVersionConflict(t *testing.T, err error, version int64) {
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict), "Version conflict expected") {
+		assert.Equal(t, "users", conflict.TableName)
+		assert.Equal(t, version, conflict.Version)
+		assert.NotNil(t, conflict.Err)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+	u := &User{}
+	u.SetItem(u).WithIdFunc(func() string {
+		return "generated"
+	})
+
+	created := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	u.touch(created)
+	assert.Equal(t, "generated", u.Id)
+	assert.Equal(t, created, u.CreatedAt)
+	assert.Equal(t, created, u.UpdatedAt)
+
+	updated := created.Add(time.Hour)
+	u.Id = "1"
+	revert := u.touch(updated)
+	assert.Equal(t, "1", u.Id)
+	assert.Equal(t, created, u.CreatedAt)
+	assert.Equal(t, updated, u.UpdatedAt)
+
+	revert()
+	assert.Equal(t, "1", u.Id)
+	assert.Equal(t, created, u.CreatedAt)
+	assert.Equal(t, created, u.UpdatedAt)
+}
+
+func TestTouchOverwrittenId(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Id   int `json:"_id" dynamodbav:"_id"`
+	}
+	u := &User{}
+	u.SetItem(u)
+
+	u.touch(time.Now())
+	assert.Equal(t, 0, u.Id, "Only string ids are generated")
+	assert.Equal(t, "", u.Item.Id)
+}
+
+func TestSaveGeneratesId(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+	d.WithIdFunc(IdKSUID)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	assert.Nil(t, u.Save())
+	assert.Len(t, u.Id, 27)
+	assert.False(t, u.CreatedAt.IsZero())
+	assert.Equal(t, u.CreatedAt, u.UpdatedAt)
+}
+
+func TestSoftDelete(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	assert.Nil(t, u.SoftDelete())
+	assert.True(t, u.Deleted)
+	assert.NotNil(t, u.DeletedAt)
+	assert.Equal(t, ErrorItemNotFound, u.SoftDelete())
+
+	_, err := tbl.Get("1")
+	assert.Equal(t, ErrorItemNotFound, err)
+
+	items, err := tbl.Scan().All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+
+	item, err := tbl.WithDeleted().Get("1")
+	if assert.Nil(t, err) {
+		assert.True(t, item.(*User).Deleted)
+	}
+
+	assert.Nil(t, u.Restore())
+	assert.False(t, u.Deleted)
+	assert.Nil(t, u.DeletedAt)
+	assert.Equal(t, ErrorItemNotFound, u.Restore())
+
+	items, err = tbl.Query("1").All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+
+	assert.Nil(t, u.Purge())
+	_, err = tbl.WithDeleted().Get("1")
+	assert.Equal(t, ErrorItemNotFound, err)
+}
+
+func TestSoftDeleteVersionConflict(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Version int    `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	// concurrent writer holding the same version
+	v := tbl.NewItem().(*User)
+	v.Id = "1"
+	v.Version = 1
+
+	u.Name = "Boris"
+	assert.Nil(t, u.Save())
+
+	assertVersionConflict(t, v.SoftDelete(), 1)
+	assert.False(t, v.Deleted)
+
+	assert.Nil(t, u.SoftDelete())
+	assert.Equal(t, ErrorItemNotFound, u.SoftDelete())
+
+	v.Id = "2"
+	assert.Equal(t, ErrorItemNotFound, v.Restore())
+}
+
+func TestSoftDeleteNotBound(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	u := &User{}
+	u.SetItem(u)
+
+	assert.Equal(t, ErrorNoSession, u.SoftDelete())
+	assert.Equal(t, ErrorNoSession, u.Restore())
+	assert.Equal(t, ErrorNoSession, u.Purge())
+}
+
+func TestSaveWithContext(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	u := tbl.NewItem().(*User)
+	u.Id = "1"
+
+	assert.True(t, errors.Is(u.SaveWithContext(ctx), context.Canceled))
+	assert.True(t, errors.Is(u.PurgeWithContext(ctx), context.Canceled))
+
+	_, err := tbl.GetWithContext(ctx, "1")
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = tbl.Query("1").AllWithContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = tbl.Scan().AllWithContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	ps := tbl.ParallelScan(2, 2)
+	for range ps.StartWithContext(ctx) {
+	}
+	assert.True(t, errors.Is(ps.Err(), context.Canceled))
+}
@@ -0,0 +1,461 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// Delay between checks of a table being updated, e.g. while an index is backfilled
+	MigrateWaitDelay time.Duration = 5 * time.Second
+)
+
+var (
+	ErrorDestructiveChange error = errors.New("Schema change requires recreating the table or its index")
+)
+
+// Returned by Migrate() when the table can only match its declaration after
+// recreating the table or some of its indexes, or changing its TTL attribute,
+// nothing is changed in this case
+type DestructiveChangeError struct {
+	TableName string
+	Changes   []*SchemaChange
+}
+
+func (e *DestructiveChangeError) Error() string {
+	var changes []string
+	for _, c := range e.Changes {
+		changes = append(changes, c.String())
+	}
+
+	return fmt.Sprintf("Table '%s' can not be migrated without ForceMigrate(): %s", e.TableName, strings.Join(changes, "; "))
+}
+
+func (e *DestructiveChangeError) Is(target error) bool {
+	return target == ErrorDestructiveChange
+}
+
+// Changes of key schema, key attribute types and local secondary indexes require recreating the table,
+// changes of a global secondary index's keys or projection require recreating the index.
+// Changing the TTL attribute disables TTL first and DynamoDB may refuse enabling it again for about an hour.
+func (c *SchemaChange) Destructive() bool {
+	switch c.Part {
+	case SchemaPartKeySchema, SchemaPartLocalSecondaryIndex:
+		return true
+	case SchemaPartAttributeDefinition, SchemaPartGlobalSecondaryIndex, SchemaPartTimeToLive:
+		return c.Action == SchemaActionModify
+	}
+
+	return false
+}
+
+// Bringing the live table to its declaration with UpdateTable: adding and removing global secondary
+// indexes, changing throughput, TTL and stream. Changes DynamoDB allows one at a time are applied
+// one by one, waiting for the table and index backfill in between.
+// DestructiveChangeError is returned for changes which need ForceMigrate().
+// The returned diff holds the changes that were due.
+func (t *Table) Migrate() (*SchemaDiff, error) {
+	return t.MigrateWithContext(context.Background())
+}
+
+// Same as Migrate() bound to the context
+func (t *Table) MigrateWithContext(ctx context.Context) (*SchemaDiff, error) {
+	return t.migrate(ctx, false)
+}
+
+// Same as Migrate() but applying destructive changes as well, the table is deleted and created
+// again for key schema, key attribute or local secondary index changes, so all its items are lost.
+// TTL moved to another attribute is disabled first, enabling it may fail for about an hour after that.
+func (t *Table) ForceMigrate() (*SchemaDiff, error) {
+	return t.ForceMigrateWithContext(context.Background())
+}
+
+// Same as ForceMigrate() bound to the context
+func (t *Table) ForceMigrateWithContext(ctx context.Context) (*SchemaDiff, error) {
+	return t.migrate(ctx, true)
+}
+
+func (t *Table) migrate(ctx context.Context, force bool) (*SchemaDiff, error) {
+	live, err := t.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := t.diff(ctx, live)
+	if err != nil {
+		return nil, err
+	}
+
+	destructive, recreate, changes := t.plan(diff, live)
+
+	if len(destructive) > 0 && !force {
+		return diff, &DestructiveChangeError{TableName: diff.TableName, Changes: destructive}
+	}
+
+	// the new table has everything declared but TTL
+	if recreate {
+		if err := t.recreate(ctx); err != nil {
+			return diff, err
+		}
+
+		remaining, err := t.diff(ctx, t.getDescription())
+		if err != nil {
+			return diff, err
+		}
+		changes = remaining.Changes
+	}
+
+	if err := t.applyChanges(ctx, changes); err != nil {
+		return diff, err
+	}
+
+	return diff, nil
+}
+
+// Picking the destructive changes and whether they need recreating the table, which is the case
+// for attributes keyed by the table or its local secondary indexes. A type change of an attribute keyed
+// only by global secondary indexes recreates just these indexes, they are added to the returned changes.
+func (t *Table) plan(diff *SchemaDiff, live *dynamodb.TableDescription) (destructive []*SchemaChange, recreate bool, changes []*SchemaChange) {
+	var keyAttributes map[string]bool = tableKeyAttributes(t.declared, live)
+
+	changes = append([]*SchemaChange{}, diff.Changes...)
+
+	for _, c := range diff.Changes {
+		if !c.Destructive() {
+			continue
+		}
+
+		destructive = append(destructive, c)
+
+		switch c.Part {
+		case SchemaPartKeySchema, SchemaPartLocalSecondaryIndex:
+			recreate = true
+		case SchemaPartAttributeDefinition:
+			if keyAttributes[c.Name] {
+				recreate = true
+				continue
+			}
+
+			changes = append(changes, t.globalSecondaryIndexChanges(c.Name, live, changes)...)
+		}
+	}
+
+	return destructive, recreate, changes
+}
+
+// Attributes keyed by the table or its local secondary indexes in any of the descriptions
+func tableKeyAttributes(descriptions ...*dynamodb.TableDescription) map[string]bool {
+	attributes := make(map[string]bool)
+
+	for _, description := range descriptions {
+		for _, k := range description.KeySchema {
+			attributes[aws.StringValue(k.AttributeName)] = true
+		}
+
+		for _, lsi := range description.LocalSecondaryIndexes {
+			for _, k := range lsi.KeySchema {
+				attributes[aws.StringValue(k.AttributeName)] = true
+			}
+		}
+	}
+
+	return attributes
+}
+
+// Recreating the declared and live global secondary indexes keyed by the attribute,
+// skipping the ones which are already changed
+func (t *Table) globalSecondaryIndexChanges(attributeName string, live *dynamodb.TableDescription, changes []*SchemaChange) []*SchemaChange {
+	var (
+		changed   map[string]bool = make(map[string]bool)
+		recreated []*SchemaChange
+	)
+
+	for _, c := range changes {
+		if c.Part == SchemaPartGlobalSecondaryIndex {
+			changed[c.Name] = true
+		}
+	}
+
+	for _, gsi := range t.declared.GlobalSecondaryIndexes {
+		name := aws.StringValue(gsi.IndexName)
+		if changed[name] || !hasKeyAttribute(gsi.KeySchema, attributeName) {
+			continue
+		}
+
+		for _, liveGsi := range live.GlobalSecondaryIndexes {
+			if aws.StringValue(liveGsi.IndexName) != name {
+				continue
+			}
+
+			recreated = append(recreated, &SchemaChange{
+				Part:     SchemaPartGlobalSecondaryIndex,
+				Action:   SchemaActionModify,
+				Name:     name,
+				Declared: indexString(gsi.KeySchema, gsi.Projection),
+				Live:     indexString(liveGsi.KeySchema, liveGsi.Projection),
+			})
+		}
+	}
+
+	return recreated
+}
+
+func hasKeyAttribute(keySchema []*dynamodb.KeySchemaElement, attributeName string) bool {
+	for _, k := range keySchema {
+		if aws.StringValue(k.AttributeName) == attributeName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Applying non table-wide changes in the order DynamoDB accepts them
+func (t *Table) applyChanges(ctx context.Context, changes []*SchemaChange) error {
+	var (
+		recreated  map[string]bool            = make(map[string]bool)
+		throughput *dynamodb.UpdateTableInput = &dynamodb.UpdateTableInput{}
+	)
+
+	// Removing indexes first, a single index per request
+	for _, c := range changes {
+		if c.Part != SchemaPartGlobalSecondaryIndex || c.Action == SchemaActionAdd {
+			continue
+		}
+
+		recreated[c.Name] = c.Action == SchemaActionModify
+
+		if err := t.update(ctx, &dynamodb.UpdateTableInput{
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(c.Name)}},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Throughput of the table and its indexes can go in a single request
+	for _, c := range changes {
+		if c.Part != SchemaPartThroughput || recreated[c.Name] {
+			continue
+		}
+
+		if c.Name == "" {
+			throughput.ProvisionedThroughput = provisionedThroughput(t.declared.ProvisionedThroughput)
+			continue
+		}
+
+		throughput.GlobalSecondaryIndexUpdates = append(throughput.GlobalSecondaryIndexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+			Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+				IndexName:             aws.String(c.Name),
+				ProvisionedThroughput: provisionedThroughput(t.declaredGlobalSecondaryIndex(c.Name).ProvisionedThroughput),
+			},
+		})
+	}
+
+	if throughput.ProvisionedThroughput != nil || len(throughput.GlobalSecondaryIndexUpdates) > 0 {
+		if err := t.update(ctx, throughput); err != nil {
+			return err
+		}
+	}
+
+	// Creating indexes one by one, each is backfilled before the next one
+	for _, c := range changes {
+		if c.Part != SchemaPartGlobalSecondaryIndex || c.Action == SchemaActionRemove {
+			continue
+		}
+
+		gsi := t.declaredGlobalSecondaryIndex(c.Name)
+
+		if err := t.update(ctx, &dynamodb.UpdateTableInput{
+			AttributeDefinitions: t.declaredAttributeDefinitions(gsi.KeySchema),
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+					IndexName:             gsi.IndexName,
+					KeySchema:             gsi.KeySchema,
+					Projection:            gsi.Projection,
+					ProvisionedThroughput: provisionedThroughput(gsi.ProvisionedThroughput),
+				}},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Stream view type can't be changed on an enabled stream, so it's disabled first
+	for _, c := range changes {
+		if c.Part != SchemaPartStream {
+			continue
+		}
+
+		if c.Action != SchemaActionAdd {
+			if err := t.update(ctx, &dynamodb.UpdateTableInput{
+				StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+			}); err != nil {
+				return err
+			}
+		}
+
+		if c.Action != SchemaActionRemove {
+			if err := t.update(ctx, &dynamodb.UpdateTableInput{
+				StreamSpecification: t.declared.StreamSpecification,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Same for TTL attribute, though DynamoDB may refuse enabling it again right after disabling
+	for _, c := range changes {
+		if c.Part != SchemaPartTimeToLive {
+			continue
+		}
+
+		if c.Action != SchemaActionAdd {
+			if err := t.updateTimeToLive(ctx, c.Live, false); err != nil {
+				return err
+			}
+		}
+
+		if c.Action != SchemaActionRemove {
+			if err := t.updateTimeToLive(ctx, c.Declared, true); err != nil {
+				if c.Action == SchemaActionModify {
+					return fmt.Errorf("TTL on '%s' is disabled, enabling it on '%s' failed: %w", c.Live, c.Declared, err)
+				}
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Sending UpdateTable request and waiting until the table is ACTIVE again
+func (t *Table) update(ctx context.Context, input *dynamodb.UpdateTableInput) error {
+	session := t.getSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	input.TableName = t.declared.TableName
+
+	if _, err := session.UpdateTableWithContext(ctx, input); err != nil {
+		return classify(err)
+	}
+
+	return t.waitUntilActive(ctx)
+}
+
+func (t *Table) updateTimeToLive(ctx context.Context, attributeName string, enabled bool) error {
+	session := t.getSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	_, err := session.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: t.declared.TableName,
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(attributeName),
+			Enabled:       aws.Bool(enabled),
+		},
+	})
+
+	return classify(err)
+}
+
+// Deleting the table and creating it from the declaration
+func (t *Table) recreate(ctx context.Context) error {
+	if err := t.DeleteWithContext(ctx); err != nil {
+		return err
+	}
+
+	if err := t.getSession().WaitUntilTableNotExistsWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: t.declared.TableName,
+	}); err != nil {
+		return classify(err)
+	}
+
+	if err := t.CreateWithContext(ctx); err != nil {
+		return err
+	}
+
+	return t.waitUntilActive(ctx)
+}
+
+// Waiting until the table and its global secondary indexes are ACTIVE,
+// the live description is kept as the table's one
+func (t *Table) waitUntilActive(ctx context.Context) error {
+	for {
+		live, err := t.describe(ctx)
+		if err != nil {
+			return err
+		}
+
+		if isActive(live) {
+			t.setDescription(live)
+			return nil
+		}
+
+		if err := aws.SleepWithContext(ctx, MigrateWaitDelay); err != nil {
+			return err
+		}
+	}
+}
+
+func isActive(live *dynamodb.TableDescription) bool {
+	if aws.StringValue(live.TableStatus) != dynamodb.TableStatusActive {
+		return false
+	}
+
+	for _, gsi := range live.GlobalSecondaryIndexes {
+		switch aws.StringValue(gsi.IndexStatus) {
+		case dynamodb.IndexStatusCreating, dynamodb.IndexStatusUpdating, dynamodb.IndexStatusDeleting:
+			return false
+		}
+
+		if aws.BoolValue(gsi.Backfilling) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t *Table) declaredGlobalSecondaryIndex(name string) *dynamodb.GlobalSecondaryIndexDescription {
+	for _, gsi := range t.declared.GlobalSecondaryIndexes {
+		if aws.StringValue(gsi.IndexName) == name {
+			return gsi
+		}
+	}
+
+	return nil
+}
+
+// Declared definitions of the key's attributes
+func (t *Table) declaredAttributeDefinitions(keySchema []*dynamodb.KeySchemaElement) []*dynamodb.AttributeDefinition {
+	var ads []*dynamodb.AttributeDefinition
+
+	for _, k := range keySchema {
+		for _, a := range t.declared.AttributeDefinitions {
+			if aws.StringValue(a.AttributeName) == aws.StringValue(k.AttributeName) {
+				ads = append(ads, a)
+			}
+		}
+	}
+
+	return ads
+}
+
+func provisionedThroughput(throughput *dynamodb.ProvisionedThroughputDescription) *dynamodb.ProvisionedThroughput {
+	return &dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  throughput.ReadCapacityUnits,
+		WriteCapacityUnits: throughput.WriteCapacityUnits,
+	}
+}
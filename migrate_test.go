@@ -0,0 +1,208 @@
+package dytona
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaChangeDestructive(t *testing.T) {
+	for _, c := range []struct {
+		change      *SchemaChange
+		destructive bool
+	}{
+		{&SchemaChange{Part: SchemaPartKeySchema, Action: SchemaActionModify}, true},
+		{&SchemaChange{Part: SchemaPartLocalSecondaryIndex, Action: SchemaActionAdd}, true},
+		{&SchemaChange{Part: SchemaPartAttributeDefinition, Action: SchemaActionModify}, true},
+		{&SchemaChange{Part: SchemaPartAttributeDefinition, Action: SchemaActionAdd}, false},
+		{&SchemaChange{Part: SchemaPartGlobalSecondaryIndex, Action: SchemaActionModify}, true},
+		{&SchemaChange{Part: SchemaPartGlobalSecondaryIndex, Action: SchemaActionAdd}, false},
+		{&SchemaChange{Part: SchemaPartGlobalSecondaryIndex, Action: SchemaActionRemove}, false},
+		{&SchemaChange{Part: SchemaPartThroughput, Action: SchemaActionModify}, false},
+		{&SchemaChange{Part: SchemaPartTimeToLive, Action: SchemaActionRemove}, false},
+		{&SchemaChange{Part: SchemaPartTimeToLive, Action: SchemaActionModify}, true},
+		{&SchemaChange{Part: SchemaPartStream, Action: SchemaActionModify}, false},
+	} {
+		assert.Equal(t, c.destructive, c.change.Destructive(), c.change.String())
+	}
+
+	err := &DestructiveChangeError{
+		TableName: "users",
+		Changes: []*SchemaChange{
+			{Part: SchemaPartKeySchema, Action: SchemaActionModify, Declared: "[id HASH]", Live: "[uuid HASH]"},
+		},
+	}
+	assert.True(t, errors.Is(err, ErrorDestructiveChange))
+	assert.EqualError(t, err, "Table 'users' can not be migrated without ForceMigrate(): key schema is [uuid HASH], declared [id HASH]")
+}
+
+func TestMigratePlan(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email int64 `json:"email" dynamodbav:"email" dynamodbat:"N" dynamodbgsi:"EmailGsi,HASH"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	live := &dynamodb.TableDescription{
+		TableName: aws.String("users"),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("email"), AttributeType: aws.String(AttributeTypeS)},
+			{AttributeName: aws.String("id"), AttributeType: aws.String(AttributeTypeS)},
+		},
+		KeySchema: tbl.declared.KeySchema,
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{
+				IndexName:             aws.String("EmailGsi"),
+				KeySchema:             tbl.declared.GlobalSecondaryIndexes[0].KeySchema,
+				Projection:            tbl.declared.GlobalSecondaryIndexes[0].Projection,
+				ProvisionedThroughput: tbl.declared.GlobalSecondaryIndexes[0].ProvisionedThroughput,
+			},
+		},
+		ProvisionedThroughput: tbl.declared.ProvisionedThroughput,
+	}
+
+	// attribute keyed only by the index recreates just the index
+	destructive, recreate, changes := tbl.plan(diffSchema(tbl.declared, live, "", ""), live)
+	assert.False(t, recreate)
+	assert.Equal(t, []string{"attribute 'email' is S, declared N"}, changeStrings(&SchemaDiff{Changes: destructive}))
+	assert.Equal(t, []string{
+		"attribute 'email' is S, declared N",
+		"global secondary index 'EmailGsi' is [email HASH] ALL, declared [email HASH] ALL",
+	}, changeStrings(&SchemaDiff{Changes: changes}))
+
+	// attribute keyed by the table recreates the table
+	live.AttributeDefinitions[1].AttributeType = aws.String(AttributeTypeN)
+	_, recreate, _ = tbl.plan(diffSchema(tbl.declared, live, "", ""), live)
+	assert.True(t, recreate)
+
+	// TTL attribute change is refused without ForceMigrate()
+	live.AttributeDefinitions[1].AttributeType = aws.String(AttributeTypeS)
+	destructive, recreate, _ = tbl.plan(diffSchema(tbl.declared, live, "expires", "expired"), live)
+	assert.False(t, recreate)
+	assert.Equal(t, []string{
+		"attribute 'email' is S, declared N",
+		"time to live is expired, declared expires",
+	}, changeStrings(&SchemaDiff{Changes: destructive}))
+}
+
+func TestIsActive(t *testing.T) {
+	live := &dynamodb.TableDescription{
+		TableStatus: aws.String(dynamodb.TableStatusActive),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{IndexName: aws.String("EmailGsi"), IndexStatus: aws.String(dynamodb.IndexStatusActive)},
+		},
+	}
+	assert.True(t, isActive(live))
+
+	live.GlobalSecondaryIndexes[0].Backfilling = aws.Bool(true)
+	assert.False(t, isActive(live))
+
+	live.GlobalSecondaryIndexes[0].Backfilling = nil
+	live.GlobalSecondaryIndexes[0].IndexStatus = aws.String(dynamodb.IndexStatusCreating)
+	assert.False(t, isActive(live))
+
+	live.GlobalSecondaryIndexes = nil
+	live.TableStatus = aws.String(dynamodb.TableStatusUpdating)
+	assert.False(t, isActive(live))
+}
+
+func TestMigrate(t *testing.T) {
+	type UserV1 struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Name  string `json:"name" dynamodbav:"name" dynamodbgsi:"NameGsi,HASH"`
+		Email string `json:"email" dynamodbav:"email"`
+	}
+
+	type UserV2 struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Email   string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,10,2"`
+		Expires int64  `json:"expires" dynamodbav:"expires" dynamodbttl:""`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	v1 := NewTable("users", func() Itemer {
+		return &UserV1{}
+	}).WithSession(d.session)
+
+	if err := v1.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+		return
+	}
+	defer v1.Delete()
+
+	v2 := NewTable("users", func() Itemer {
+		return &UserV2{}
+	}).WithSession(d.session).WithStream(StreamViewTypeNEWIMAGE)
+
+	diff, err := v2.Migrate()
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{
+			"attribute 'email' is missing, declared S",
+			"attribute 'name' is not declared, live S",
+			"global secondary index 'EmailGsi' is missing, declared [email HASH] ALL",
+			"global secondary index 'NameGsi' is not declared, live [name HASH] ALL",
+			"time to live is missing, declared expires",
+			"stream is missing, declared NEW_IMAGE",
+		}, changeStrings(diff))
+	}
+
+	diff, err = v2.Diff()
+	if assert.Nil(t, err) {
+		assert.True(t, diff.Empty(), diff.String())
+	}
+}
+
+func TestMigrateDestructive(t *testing.T) {
+	type UserV1 struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	type UserV2 struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	v1 := NewTable("users", func() Itemer {
+		return &UserV1{}
+	}).WithSession(d.session)
+
+	if err := v1.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+		return
+	}
+
+	v2 := NewTable("users", func() Itemer {
+		return &UserV2{}
+	}).WithSession(d.session)
+	defer v2.Delete()
+
+	_, err := v2.Migrate()
+	assert.True(t, errors.Is(err, ErrorDestructiveChange))
+
+	var destructive *DestructiveChangeError
+	if assert.True(t, errors.As(err, &destructive)) {
+		assert.Equal(t, []string{
+			"key schema is [id HASH], declared [uuid HASH]",
+		}, changeStrings(&SchemaDiff{Changes: destructive.Changes}))
+	}
+
+	_, err = v2.ForceMigrate()
+	assert.Nil(t, err)
+
+	diff, err := v2.Diff()
+	if assert.Nil(t, err) {
+		assert.True(t, diff.Empty(), diff.String())
+	}
+}
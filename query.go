@@ -0,0 +1,278 @@
+package dytona
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	ConditionEQ         string = "="
+	ConditionLT         string = "<"
+	ConditionLE         string = "<="
+	ConditionGT         string = ">"
+	ConditionGE         string = ">="
+	ConditionBETWEEN    string = "BETWEEN"
+	ConditionBEGINSWITH string = "begins_with"
+)
+
+// Fluent builder for key-condition queries, e.g.
+//
+//	items, err := tbl.Query("uuid").RangeGt(time.Now()).Desc().Limit(10).All()
+type Query struct {
+	table *Table
+	index *Index
+
+	hashKey        interface{}
+	rangeCondition string
+	rangeValues    []interface{}
+
+	filter         string
+	filterValues   []interface{}
+	projection     []string
+	limit          int64
+	descending     bool
+	consistentRead bool
+	withDeleted    bool
+}
+
+// Starting a query for items with the given hash key value
+func (t *Table) Query(hashKey interface{}) *Query {
+	return &Query{table: t, hashKey: hashKey}
+}
+
+func (q *Query) RangeEq(value interface{}) *Query {
+	return q.rangeKey(ConditionEQ, value)
+}
+
+func (q *Query) RangeLt(value interface{}) *Query {
+	return q.rangeKey(ConditionLT, value)
+}
+
+func (q *Query) RangeLe(value interface{}) *Query {
+	return q.rangeKey(ConditionLE, value)
+}
+
+func (q *Query) RangeGt(value interface{}) *Query {
+	return q.rangeKey(ConditionGT, value)
+}
+
+func (q *Query) RangeGe(value interface{}) *Query {
+	return q.rangeKey(ConditionGE, value)
+}
+
+func (q *Query) RangeBetween(from, to interface{}) *Query {
+	return q.rangeKey(ConditionBETWEEN, from, to)
+}
+
+func (q *Query) RangeBeginsWith(prefix string) *Query {
+	return q.rangeKey(ConditionBEGINSWITH, prefix)
+}
+
+func (q *Query) rangeKey(condition string, values ...interface{}) *Query {
+	q.rangeCondition = condition
+	q.rangeValues = values
+	return q
+}
+
+// Setting a filter expression applied after the key condition,
+// `#attribute` are attribute names and `?` are values in order, e.g.
+//
+//	Filter("#age > ? AND #deleted = ?", 18, false)
+func (q *Query) Filter(expression string, values ...interface{}) *Query {
+	q.filter = expression
+	q.filterValues = values
+	return q
+}
+
+// Limiting the returned attributes
+func (q *Query) Project(attributeNames ...string) *Query {
+	q.projection = attributeNames
+	return q
+}
+
+// Limiting the number of returned items
+func (q *Query) Limit(limit int64) *Query {
+	q.limit = limit
+	return q
+}
+
+// Sorting items by range key in descending order
+func (q *Query) Desc() *Query {
+	q.descending = true
+	return q
+}
+
+func (q *Query) ConsistentRead() *Query {
+	q.consistentRead = true
+	return q
+}
+
+// Including soft-deleted items into the results
+func (q *Query) WithDeleted() *Query {
+	q.withDeleted = true
+	return q
+}
+
+// Running the query and decoding all the pages into the registered item type
+func (q *Query) All() ([]Itemer, error) {
+	return q.AllWithContext(context.Background())
+}
+
+// Same as All() bound to the context
+func (q *Query) AllWithContext(ctx context.Context) ([]Itemer, error) {
+	var items []Itemer
+
+	session := q.table.getSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	input, err := q.input()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		out, err := session.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, classify(err)
+		}
+
+		for _, av := range out.Items {
+			item, err := q.table.decodeItem(av)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		if q.limit > 0 && int64(len(items)) >= q.limit {
+			return items[:q.limit], nil
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+
+	return items, nil
+}
+
+// Running the query and getting the first item only
+func (q *Query) One() (Itemer, error) {
+	return q.OneWithContext(context.Background())
+}
+
+// Same as One() bound to the context
+func (q *Query) OneWithContext(ctx context.Context) (Itemer, error) {
+	items, err := q.Limit(1).AllWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrorItemNotFound
+	}
+
+	return items[0], nil
+}
+
+func (q *Query) input() (*dynamodb.QueryInput, error) {
+	var (
+		e                 *expression = newExpression()
+		hashName, rngName string      = q.table.keyNames()
+		filter            string
+	)
+
+	input := &dynamodb.QueryInput{
+		TableName:        q.table.getDescription().TableName,
+		ScanIndexForward: aws.Bool(!q.descending),
+		ConsistentRead:   aws.Bool(q.consistentRead),
+	}
+
+	if q.index != nil {
+		if q.index.err != nil {
+			return nil, q.index.err
+		}
+
+		if q.index.global && q.consistentRead {
+			return nil, ErrorConsistentReadOnGlobalIndex
+		}
+
+		// filtering by not projected attributes would silently match nothing,
+		// the same goes for excluding soft-deleted items unless WithDeleted() is used
+		attributeNames := append(append([]string{}, q.projection...), expressionNames(q.filter)...)
+		if name := q.table.deletedAttributeName(); !q.withDeleted && !q.table.withDeleted && name != "" && name != "-" {
+			attributeNames = append(attributeNames, name)
+		}
+
+		if err := q.index.checkProjection(attributeNames); err != nil {
+			return nil, err
+		}
+
+		hashName, rngName = q.index.keyNames()
+		input.IndexName = aws.String(q.index.name)
+	}
+
+	if q.hashKey == nil {
+		return nil, &MissingKeyError{AttributeName: hashName, KeyType: KeyTypeHASH}
+	}
+
+	keyCondition := fmt.Sprintf("%s = %s", e.name(hashName), e.value(q.hashKey))
+
+	if q.rangeCondition != "" {
+		if rngName == "" {
+			return nil, ErrorUnexpectedRangeKey
+		}
+
+		keyCondition += " AND " + rangeKeyCondition(e, rngName, q.rangeCondition, q.rangeValues)
+	}
+
+	input.KeyConditionExpression = aws.String(keyCondition)
+
+	if q.filter != "" {
+		filter = e.parse(q.filter, q.filterValues)
+	}
+
+	if len(q.projection) > 0 {
+		input.ProjectionExpression = aws.String(e.projection(q.projection))
+	}
+
+	if !q.withDeleted && !q.table.withDeleted {
+		filter = joinFilters(filter, q.table.notDeletedFilter(e))
+	}
+
+	if filter != "" {
+		input.FilterExpression = aws.String(filter)
+	}
+
+	if q.limit > 0 {
+		input.Limit = aws.Int64(q.limit)
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	input.ExpressionAttributeNames = e.attributeNames()
+	input.ExpressionAttributeValues = e.attributeValues()
+
+	return input, nil
+}
+
+func rangeKeyCondition(e *expression, attributeName, condition string, values []interface{}) string {
+	switch condition {
+	case ConditionBETWEEN:
+		return fmt.Sprintf("%s BETWEEN %s AND %s", e.name(attributeName), e.value(values[0]), e.value(values[1]))
+	case ConditionBEGINSWITH:
+		return fmt.Sprintf("begins_with(%s, %s)", e.name(attributeName), e.value(values[0]))
+	}
+
+	return fmt.Sprintf("%s %s %s", e.name(attributeName), condition, e.value(values[0]))
+}
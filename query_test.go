@@ -0,0 +1,163 @@
+package dytona
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryInput(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string    `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date time.Time `json:"time" dynamodbav:"time" dynamodbpk:"RANGE"`
+		Name string    `json:"name" dynamodbav:"name"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	input, err := tbl.Query("1").
+		RangeBetween("2017-01-01", "2017-02-01").
+		Filter("begins_with(#name, ?)", "Ro").
+		Project("uuid", "name").
+		Limit(10).
+		Desc().
+		ConsistentRead().
+		input()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "users", *input.TableName)
+	assert.Equal(t, "#n0 = :v0 AND #n1 BETWEEN :v1 AND :v2", *input.KeyConditionExpression)
+	assert.Equal(t, "(begins_with(#n2, :v3)) AND (attribute_not_exists(#n3) OR #n3 = :v4)", *input.FilterExpression)
+	assert.Equal(t, "#n0, #n2", *input.ProjectionExpression)
+	assert.Equal(t, map[string]*string{
+		"#n0": aws.String("uuid"),
+		"#n1": aws.String("time"),
+		"#n2": aws.String("name"),
+		"#n3": aws.String("deleted"),
+	}, input.ExpressionAttributeNames)
+	assert.Equal(t, map[string]*dynamodb.AttributeValue{
+		":v0": {S: aws.String("1")},
+		":v1": {S: aws.String("2017-01-01")},
+		":v2": {S: aws.String("2017-02-01")},
+		":v3": {S: aws.String("Ro")},
+		":v4": {BOOL: aws.Bool(false)},
+	}, input.ExpressionAttributeValues)
+	assert.Equal(t, int64(10), *input.Limit)
+	assert.False(t, *input.ScanIndexForward)
+	assert.True(t, *input.ConsistentRead)
+
+	input, err = tbl.Query("1").Filter("begins_with(#name, ?)", "Ro").WithDeleted().input()
+	assert.Nil(t, err)
+	assert.Equal(t, "begins_with(#n1, :v1)", *input.FilterExpression)
+
+	input, err = tbl.WithDeleted().Query("1").input()
+	assert.Nil(t, err)
+	assert.Nil(t, input.FilterExpression)
+}
+
+func TestQueryInputConditions(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Count int    `json:"count" dynamodbav:"count" dynamodbpk:"RANGE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	for expected, q := range map[string]*Query{
+		"#n0 = :v0":                           tbl.Query("1"),
+		"#n0 = :v0 AND #n1 = :v1":             tbl.Query("1").RangeEq(1),
+		"#n0 = :v0 AND #n1 < :v1":             tbl.Query("1").RangeLt(1),
+		"#n0 = :v0 AND #n1 <= :v1":            tbl.Query("1").RangeLe(1),
+		"#n0 = :v0 AND #n1 > :v1":             tbl.Query("1").RangeGt(1),
+		"#n0 = :v0 AND #n1 >= :v1":            tbl.Query("1").RangeGe(1),
+		"#n0 = :v0 AND begins_with(#n1, :v1)": tbl.Query("1").RangeBeginsWith("1"),
+	} {
+		input, err := q.input()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, *input.KeyConditionExpression)
+	}
+}
+
+func TestQueryInputErrors(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	{
+		_, err := tbl.Query(nil).input()
+		assert.Equal(t, &MissingKeyError{AttributeName: "id", KeyType: KeyTypeHASH}, err)
+	}
+
+	{
+		_, err := tbl.Query("1").RangeEq(1).input()
+		assert.Equal(t, ErrorUnexpectedRangeKey, err)
+	}
+
+	{
+		_, err := tbl.Query("1").Filter("#a = ? AND #b = ?", 1).input()
+		assert.Equal(t, ErrorExpressionValues, err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Count int    `json:"count" dynamodbav:"count" dynamodbpk:"RANGE"`
+		Name  string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	for i := 1; i <= 5; i++ {
+		u := tbl.NewItem().(*User)
+		u.UUID = "1"
+		u.Count = i
+		u.Name = "Roman"
+		assert.Nil(t, u.Save())
+	}
+
+	{
+		items, err := tbl.Query("1").RangeGt(2).Desc().All()
+		assert.Nil(t, err)
+		if assert.Len(t, items, 3) {
+			assert.IsType(t, &User{}, items[0])
+			assert.Equal(t, 5, items[0].(*User).Count)
+		}
+	}
+
+	{
+		items, err := tbl.Query("1").Filter("#count <> ?", 3).Limit(2).All()
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+	}
+
+	{
+		item, err := tbl.Query("2").One()
+		assert.Nil(t, item)
+		assert.Equal(t, ErrorItemNotFound, err)
+	}
+}
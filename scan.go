@@ -0,0 +1,384 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	ErrorScanCanceled error = errors.New("Scan canceled")
+	ErrorScanSegments error = errors.New("Scan segments and workers have to be positive numbers")
+)
+
+// Fluent builder for full table scans, e.g.
+//
+//	items, err := tbl.Scan().Filter("#age > ?", 18).Project("id", "age").All()
+type Scan struct {
+	table *Table
+
+	filter         string
+	filterValues   []interface{}
+	projection     []string
+	limit          int64
+	consistentRead bool
+	withDeleted    bool
+}
+
+// Starting a scan over all the table's items
+func (t *Table) Scan() *Scan {
+	return &Scan{table: t}
+}
+
+// Setting a filter expression applied to every scanned item,
+// `#attribute` are attribute names and `?` are values in order
+func (s *Scan) Filter(expression string, values ...interface{}) *Scan {
+	s.filter = expression
+	s.filterValues = values
+	return s
+}
+
+// Limiting the returned attributes
+func (s *Scan) Project(attributeNames ...string) *Scan {
+	s.projection = attributeNames
+	return s
+}
+
+// Limiting the number of returned items
+func (s *Scan) Limit(limit int64) *Scan {
+	s.limit = limit
+	return s
+}
+
+func (s *Scan) ConsistentRead() *Scan {
+	s.consistentRead = true
+	return s
+}
+
+// Including soft-deleted items into the results
+func (s *Scan) WithDeleted() *Scan {
+	s.withDeleted = true
+	return s
+}
+
+// Running the scan and decoding all the pages into the registered item type
+func (s *Scan) All() ([]Itemer, error) {
+	return s.AllWithContext(context.Background())
+}
+
+// Same as All() bound to the context
+func (s *Scan) AllWithContext(ctx context.Context) ([]Itemer, error) {
+	var items []Itemer
+
+	session := s.table.getSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	input, err := s.input()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		out, err := session.ScanWithContext(ctx, input)
+		if err != nil {
+			return nil, classify(err)
+		}
+
+		for _, av := range out.Items {
+			item, err := s.table.decodeItem(av)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		if s.limit > 0 && int64(len(items)) >= s.limit {
+			return items[:s.limit], nil
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+
+	return items, nil
+}
+
+func (s *Scan) input() (*dynamodb.ScanInput, error) {
+	var (
+		e      *expression = newExpression()
+		filter string
+	)
+
+	input := &dynamodb.ScanInput{
+		TableName:      s.table.getDescription().TableName,
+		ConsistentRead: aws.Bool(s.consistentRead),
+	}
+
+	if s.filter != "" {
+		filter = e.parse(s.filter, s.filterValues)
+	}
+
+	if len(s.projection) > 0 {
+		input.ProjectionExpression = aws.String(e.projection(s.projection))
+	}
+
+	if !s.withDeleted && !s.table.withDeleted {
+		filter = joinFilters(filter, s.table.notDeletedFilter(e))
+	}
+
+	if filter != "" {
+		input.FilterExpression = aws.String(filter)
+	}
+
+	if s.limit > 0 {
+		input.Limit = aws.Int64(s.limit)
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	input.ExpressionAttributeNames = e.attributeNames()
+	input.ExpressionAttributeValues = e.attributeValues()
+
+	return input, nil
+}
+
+// Progress of a single parallel scan segment, reported after every page
+type SegmentProgress struct {
+	Segment      int
+	Pages        int
+	Items        int
+	ScannedItems int
+	Done         bool
+}
+
+// Segmented scan run by a pool of workers, streaming decoded items over a channel, e.g.
+//
+//	ps := tbl.ParallelScan(8, 4).Filter("#age > ?", 18)
+//	for item := range ps.Start() {
+//		...
+//	}
+//	if err := ps.Err(); err != nil {
+//		...
+//	}
+type ParallelScan struct {
+	scan       *Scan
+	segments   int
+	workers    int
+	onProgress func(SegmentProgress)
+
+	mu       sync.Mutex
+	err      error
+	finished bool
+	once     sync.Once
+	cancel   chan struct{}
+}
+
+// Starting a parallel scan split into the number of segments, scanned by the number of workers
+func (t *Table) ParallelScan(segments, workers int) *ParallelScan {
+	return &ParallelScan{
+		scan:     t.Scan(),
+		segments: segments,
+		workers:  workers,
+		cancel:   make(chan struct{}),
+	}
+}
+
+// Setting a filter expression applied to every scanned item,
+// `#attribute` are attribute names and `?` are values in order
+func (p *ParallelScan) Filter(expression string, values ...interface{}) *ParallelScan {
+	p.scan.Filter(expression, values...)
+	return p
+}
+
+// Limiting the returned attributes
+func (p *ParallelScan) Project(attributeNames ...string) *ParallelScan {
+	p.scan.Project(attributeNames...)
+	return p
+}
+
+func (p *ParallelScan) ConsistentRead() *ParallelScan {
+	p.scan.ConsistentRead()
+	return p
+}
+
+// Including soft-deleted items into the results
+func (p *ParallelScan) WithDeleted() *ParallelScan {
+	p.scan.WithDeleted()
+	return p
+}
+
+// Setting a callback for per-segment progress, it is called from the worker goroutines
+func (p *ParallelScan) OnProgress(f func(SegmentProgress)) *ParallelScan {
+	p.onProgress = f
+	return p
+}
+
+// Running the scan, the returned channel is closed once all the segments are done,
+// the scan is canceled or has failed. Cancel() has to be called if the channel is not drained.
+func (p *ParallelScan) Start() <-chan Itemer {
+	return p.StartWithContext(context.Background())
+}
+
+// Same as Start() bound to the context
+func (p *ParallelScan) StartWithContext(ctx context.Context) <-chan Itemer {
+	var (
+		items    chan Itemer   = make(chan Itemer)
+		segments chan int      = make(chan int)
+		done     chan struct{} = make(chan struct{})
+		wg       sync.WaitGroup
+	)
+
+	if p.segments < 1 || p.workers < 1 {
+		p.fail(ErrorScanSegments)
+		close(items)
+		return items
+	}
+
+	session := p.scan.table.getSession()
+	if session == nil {
+		p.fail(ErrorNoSession)
+		close(items)
+		return items
+	}
+
+	// stopping the workers once the context is done
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.fail(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	go func() {
+		defer close(segments)
+
+		for segment := 0; segment < p.segments; segment++ {
+			select {
+			case segments <- segment:
+			case <-p.cancel:
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < p.workers && w < p.segments; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for segment := range segments {
+				if err := p.scanSegment(ctx, session, segment, items); err != nil {
+					p.fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+
+		p.mu.Lock()
+		p.finished = true
+		p.mu.Unlock()
+
+		close(done)
+		close(items)
+	}()
+
+	return items
+}
+
+// Stopping all the workers, Err() returns ErrorScanCanceled afterwards.
+// It's a no-op once all the workers are done.
+func (p *ParallelScan) Cancel() {
+	p.fail(ErrorScanCanceled)
+}
+
+// Getting the first error happened during the scan
+func (p *ParallelScan) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Keeping the first error only and stopping the rest of the workers,
+// nothing can fail after the workers are done
+func (p *ParallelScan) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil && !p.finished {
+		p.err = err
+	}
+	p.mu.Unlock()
+
+	p.once.Do(func() {
+		close(p.cancel)
+	})
+}
+
+func (p *ParallelScan) scanSegment(ctx context.Context, session *dynamodb.DynamoDB, segment int, items chan<- Itemer) error {
+	var progress SegmentProgress = SegmentProgress{Segment: segment}
+
+	input, err := p.scan.input()
+	if err != nil {
+		return err
+	}
+
+	input.Segment = aws.Int64(int64(segment))
+	input.TotalSegments = aws.Int64(int64(p.segments))
+
+	for {
+		select {
+		case <-p.cancel:
+			return nil
+		default:
+		}
+
+		out, err := session.ScanWithContext(ctx, input)
+		if err != nil {
+			return classify(err)
+		}
+
+		for _, av := range out.Items {
+			item, err := p.scan.table.decodeItem(av)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case items <- item:
+			case <-p.cancel:
+				return nil
+			}
+		}
+
+		progress.Pages++
+		progress.Items += len(out.Items)
+		progress.ScannedItems += int(aws.Int64Value(out.ScannedCount))
+		progress.Done = len(out.LastEvaluatedKey) == 0
+
+		if p.onProgress != nil {
+			p.onProgress(progress)
+		}
+
+		if progress.Done {
+			return nil
+		}
+
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
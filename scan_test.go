@@ -0,0 +1,210 @@
+package dytona
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanInput(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Age  int `json:"age" dynamodbav:"age"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	{
+		input, err := tbl.Scan().input()
+		assert.Nil(t, err)
+		assert.Equal(t, "users", *input.TableName)
+		assert.Equal(t, "attribute_not_exists(#n0) OR #n0 = :v0", *input.FilterExpression)
+		assert.Nil(t, input.ProjectionExpression)
+		assert.Equal(t, map[string]*string{"#n0": aws.String("deleted")}, input.ExpressionAttributeNames)
+		assert.Equal(t, map[string]*dynamodb.AttributeValue{":v0": {BOOL: aws.Bool(false)}}, input.ExpressionAttributeValues)
+		assert.Nil(t, input.Limit)
+	}
+
+	{
+		input, err := tbl.Scan().WithDeleted().input()
+		assert.Nil(t, err)
+		assert.Nil(t, input.FilterExpression)
+		assert.Nil(t, input.ExpressionAttributeNames)
+		assert.Nil(t, input.ExpressionAttributeValues)
+	}
+
+	{
+		input, err := tbl.Scan().
+			Filter("#age BETWEEN ? AND ?", 18, 30).
+			Project("id", "age").
+			Limit(5).
+			ConsistentRead().
+			input()
+
+		assert.Nil(t, err)
+		assert.Equal(t, "(#n0 BETWEEN :v0 AND :v1) AND (attribute_not_exists(#n2) OR #n2 = :v2)", *input.FilterExpression)
+		assert.Equal(t, "#n1, #n0", *input.ProjectionExpression)
+		assert.Equal(t, map[string]*string{
+			"#n0": aws.String("age"),
+			"#n1": aws.String("id"),
+			"#n2": aws.String("deleted"),
+		}, input.ExpressionAttributeNames)
+		assert.Equal(t, map[string]*dynamodb.AttributeValue{
+			":v0": {N: aws.String("18")},
+			":v1": {N: aws.String("30")},
+			":v2": {BOOL: aws.Bool(false)},
+		}, input.ExpressionAttributeValues)
+		assert.Equal(t, int64(5), *input.Limit)
+		assert.True(t, *input.ConsistentRead)
+	}
+}
+
+func TestScan(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Age  int `json:"age" dynamodbav:"age"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	for _, id := range []string{"1", "2", "3"} {
+		u := tbl.NewItem().(*User)
+		u.Id = id
+		u.Age = len(id) * 20
+		assert.Nil(t, u.Save())
+	}
+
+	items, err := tbl.Scan().Filter("#age > ?", 10).All()
+	assert.Nil(t, err)
+	if assert.Len(t, items, 3) {
+		assert.IsType(t, &User{}, items[0])
+		assert.Equal(t, d.session, items[0].(*User).session)
+	}
+
+	items, err = tbl.Scan().Limit(2).All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestParallelScanInvalidSegments(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	ps := tbl.ParallelScan(0, 1)
+	for range ps.Start() {
+		assert.Fail(t, "No items expected")
+	}
+	assert.Equal(t, ErrorScanSegments, ps.Err())
+
+	ps = tbl.ParallelScan(2, 2)
+	for range ps.Start() {
+		assert.Fail(t, "No items expected")
+	}
+	assert.Equal(t, ErrorNoSession, ps.Err())
+}
+
+func TestParallelScan(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Age  int `json:"age" dynamodbav:"age"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		u := tbl.NewItem().(*User)
+		u.Id = id
+		u.Age = 20
+		assert.Nil(t, u.Save())
+	}
+
+	var (
+		mu       sync.Mutex
+		done     map[int]bool = make(map[int]bool)
+		received int
+	)
+
+	ps := tbl.ParallelScan(3, 2).
+		Filter("#age = ?", 20).
+		OnProgress(func(p SegmentProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			done[p.Segment] = p.Done
+		})
+
+	for item := range ps.Start() {
+		assert.IsType(t, &User{}, item)
+		received++
+	}
+
+	assert.Nil(t, ps.Err())
+
+	// Canceling the finished scan
+	ps.Cancel()
+	assert.Nil(t, ps.Err())
+	assert.Equal(t, 5, received)
+	assert.Equal(t, map[int]bool{0: true, 1: true, 2: true}, done)
+}
+
+func TestParallelScanCancel(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	for _, id := range []string{"1", "2", "3"} {
+		u := tbl.NewItem().(*User)
+		u.Id = id
+		assert.Nil(t, u.Save())
+	}
+
+	ps := tbl.ParallelScan(1, 1)
+	items := ps.Start()
+	<-items
+	ps.Cancel()
+
+	for range items {
+	}
+	assert.Equal(t, ErrorScanCanceled, ps.Err())
+}
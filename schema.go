@@ -0,0 +1,149 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// What EnsureTables() has found or done for a table
+type EnsureStatus string
+
+const (
+	EnsureCreated EnsureStatus = "created"
+	EnsureExisted EnsureStatus = "existed"
+	EnsureDiffers EnsureStatus = "differs"
+)
+
+// Result of ensuring a single table, Diff is set for tables which already existed
+type EnsureResult struct {
+	TableName string
+	Status    EnsureStatus
+	Diff      *SchemaDiff
+}
+
+// Making sure every registered table exists and is ACTIVE, creating the missing ones,
+// the results are in the order of table names
+func (d *Dytona) EnsureTables() ([]*EnsureResult, error) {
+	return d.EnsureTablesWithContext(context.Background())
+}
+
+// Same as EnsureTables() bound to the context
+func (d *Dytona) EnsureTablesWithContext(ctx context.Context) ([]*EnsureResult, error) {
+	var results []*EnsureResult
+
+	for _, t := range d.tables() {
+		result, err := t.EnsureWithContext(ctx)
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Making sure the table exists and is ACTIVE, creating it if missing
+// along with its TTL, which Create() leaves disabled
+func (t *Table) Ensure() (*EnsureResult, error) {
+	return t.EnsureWithContext(context.Background())
+}
+
+// Same as Ensure() bound to the context
+func (t *Table) EnsureWithContext(ctx context.Context) (*EnsureResult, error) {
+	var result *EnsureResult = &EnsureResult{TableName: t.Name(), Status: EnsureExisted}
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	live, err := t.describe(ctx)
+
+	switch {
+	case errors.Is(err, ErrTableNotFound):
+		err := t.CreateWithContext(ctx)
+
+		// the table could have been created by another process in the meantime
+		switch {
+		case errors.Is(err, ErrTableExists):
+			break
+		case err != nil:
+			return nil, err
+		default:
+			result.Status = EnsureCreated
+		}
+
+	case err != nil:
+		return nil, err
+	}
+
+	if live == nil || aws.StringValue(live.TableStatus) != dynamodb.TableStatusActive {
+		if err := t.getSession().WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{
+			TableName: t.declared.TableName,
+		}); err != nil {
+			return nil, classify(err)
+		}
+
+		if live, err = t.describe(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	t.setDescription(live)
+
+	// TTL can't be set by CreateTable, it's enabled on the ACTIVE table
+	if result.Status == EnsureCreated && t.timeToLive != "" {
+		if err := t.updateTimeToLive(ctx, t.timeToLive, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Status == EnsureExisted {
+		if result.Diff, err = t.diff(ctx, live); err != nil {
+			return nil, err
+		}
+
+		if !result.Diff.Empty() {
+			result.Status = EnsureDiffers
+		}
+	}
+
+	return result, nil
+}
+
+func (t *Table) describe(ctx context.Context) (*dynamodb.TableDescription, error) {
+	session := t.getSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	out, err := session.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: t.declared.TableName,
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	return out.Table, nil
+}
+
+// Getting the registered tables sorted by name
+func (d *Dytona) tables() []*Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make([]*Table, 0, len(d.registry))
+	for _, t := range d.registry {
+		tables = append(tables, t)
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name() < tables[j].Name()
+	})
+
+	return tables
+}
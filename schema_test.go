@@ -0,0 +1,123 @@
+package dytona
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureNoSession(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	results, err := d.EnsureTables()
+	assert.Equal(t, ErrorNoSession, err)
+	assert.Empty(t, results)
+}
+
+func TestEnsureMalformed(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,5"`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	_, err := tbl.Ensure()
+	assert.NotNil(t, tbl.Err())
+	assert.Equal(t, tbl.Err(), err)
+}
+
+func TestEnsureTables(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH"`
+	}
+
+	type Post struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	users := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+	posts := d.RegisterTable("posts", func() Itemer {
+		return &Post{}
+	})
+
+	if err := posts.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer posts.Delete()
+
+	results, err := d.EnsureTables()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer users.Delete()
+
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "posts", results[0].TableName)
+		assert.Equal(t, EnsureExisted, results[0].Status)
+		assert.True(t, results[0].Diff.Empty())
+
+		assert.Equal(t, &EnsureResult{TableName: "users", Status: EnsureCreated}, results[1])
+	}
+	assert.Equal(t, dynamodb.TableStatusActive, *users.Description().TableStatus)
+	assert.Equal(t, dynamodb.TableStatusActive, *posts.Description().TableStatus)
+
+	// Re-registering the table without the index
+	d2 := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d2.Dial(NewConfig().WithMaxRetries(0))
+	d2.RegisterTable("users", func() Itemer {
+		return &Post{}
+	})
+
+	results, err = d2.EnsureTables()
+	if assert.Nil(t, err) && assert.Len(t, results, 1) {
+		assert.Equal(t, EnsureDiffers, results[0].Status)
+		assert.Equal(t, []string{
+			"attribute 'email' is not declared, live S",
+			"global secondary index 'EmailGsi' is not declared, live [email HASH] ALL",
+		}, changeStrings(results[0].Diff))
+	}
+}
+
+func TestEnsureTimeToLive(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Expires int64 `json:"expires" dynamodbav:"expires" dynamodbttl:""`
+	}
+
+	d := NewDytona("1", "2", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	result, err := tbl.Ensure()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer tbl.Delete()
+	assert.Equal(t, EnsureCreated, result.Status)
+
+	diff, err := tbl.Diff()
+	if assert.Nil(t, err) {
+		assert.True(t, diff.Empty(), "Created table should have its TTL enabled")
+	}
+}
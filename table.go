@@ -1,13 +1,19 @@
 package dytona
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/imdario/mergo"
 )
 
@@ -29,32 +35,100 @@ const (
 	KeyProjectionTypeINCLUDE  string = "INCLUDE"
 	KeyProjectionTypeALL      string = "ALL"
 
+	StreamViewTypeKEYSONLY        string = "KEYS_ONLY"
+	StreamViewTypeNEWIMAGE        string = "NEW_IMAGE"
+	StreamViewTypeOLDIMAGE        string = "OLD_IMAGE"
+	StreamViewTypeNEWANDOLDIMAGES string = "NEW_AND_OLD_IMAGES"
+
 	TagAttributeValue       string = "dynamodbav"
 	TagAttributeType        string = "dynamodbat"
 	TagPrimaryKey           string = "dynamodbpk"
 	TagLocalSecondaryIndex  string = "dynamodblsi"
 	TagGlobalSecondaryIndex string = "dynamodbgsi"
+	TagVersion              string = "dynamodbversion"
+	TagTimeToLive           string = "dynamodbttl"
+)
+
+var (
+	ErrorItemNotFound       error = ErrNotFound
+	ErrorUnexpectedRangeKey error = errors.New("Table has no RANGE key")
 )
 
+// Returned for malformed index struct tags
+type IndexTagError struct {
+	Field string
+	Tag   string
+	Err   error
+}
+
+func (e *IndexTagError) Error() string {
+	return fmt.Sprintf("Field '%s' has malformed `%s` tag: %s", e.Field, e.Tag, e.Err.Error())
+}
+
+// Returned for index declarations DynamoDB would not accept
+type IndexError struct {
+	IndexName string
+	Reason    string
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("Index '%s' is invalid: %s", e.IndexName, e.Reason)
+}
+
 type Table struct {
+	// guarding the description replaced on create, delete and migrations, and the id strategy
+	mu          *sync.RWMutex
 	description *dynamodb.TableDescription
+	declared    *dynamodb.TableDescription
 	session     *dynamodb.DynamoDB
+	dytona      *Dytona
 	newItemFunc func() Itemer
+	idFunc      IdFunc
+
+	// first malformed index declaration of the item's struct tags, returned by Create()
+	err error
+
+	// attribute declared with `dynamodbttl` tag, empty when the table has no TTL
+	timeToLive string
+
+	// soft-deleted items are excluded from Get(), Query() and Scan() unless set
+	withDeleted bool
 }
 
 func NewTable(name string, newItemFunc func() Itemer) *Table {
-	t := &Table{newItemFunc: newItemFunc}
+	t := &Table{newItemFunc: newItemFunc, mu: &sync.RWMutex{}}
 
+	// table name has to be known before the rest of the description,
+	// since NewItem() binds it to every created item
 	t.description = &dynamodb.TableDescription{
-		TableName:            aws.String(name),
-		AttributeDefinitions: t.attributeDefinitions(),
-		KeySchema:            t.keySchema(),
-		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
-			ReadCapacityUnits:  aws.Int64(5),
-			WriteCapacityUnits: aws.Int64(5),
-		},
+		TableName: aws.String(name),
+	}
+
+	t.description.AttributeDefinitions = t.attributeDefinitions()
+	t.description.KeySchema = t.keySchema()
+	t.description.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+		ReadCapacityUnits:  aws.Int64(5),
+		WriteCapacityUnits: aws.Int64(5),
 	}
 
+	// malformed index declarations are kept to be returned by Err() and Create()
+	lsis, err := t.localSecondaryIndexes()
+	if err != nil {
+		t.err = err
+	}
+	t.description.LocalSecondaryIndexes = localSecondaryIndexDescriptions(lsis)
+
+	gsis, err := t.globalSecondaryIndexes()
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	t.description.GlobalSecondaryIndexes = globalSecondaryIndexDescriptions(gsis)
+
+	t.timeToLive = t.timeToLiveAttribute()
+
+	// the description is replaced by the live one on create and refresh
+	t.declared = t.description
+
 	return t
 }
 
@@ -62,61 +136,363 @@ func (t *Table) NewItem() Itemer {
 	item := t.newItemFunc()
 
 	return item.SetItem(item).
-		WithSession(t.session)
+		WithTableName(t.Name()).
+		WithSession(t.getSession()).
+		WithIdFunc(t.getIdFunc())
 }
 
+// Binding the table to the session, a registered table stops following its Dytona's session
 func (t *Table) WithSession(session *dynamodb.DynamoDB) *Table {
 	t.session = session
+	t.dytona = nil
 	return t
 }
 
+// Registered tables use the current session of their Dytona
+func (t *Table) getSession() *dynamodb.DynamoDB {
+	if t.dytona != nil {
+		return t.dytona.GetSession()
+	}
+
+	return t.session
+}
+
+// Setting the strategy for generating an empty `Id` of the table's items,
+// DefaultIdFunc is used when not set
+func (t *Table) WithIdFunc(idFunc IdFunc) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.idFunc = idFunc
+	return t
+}
+
+func (t *Table) getIdFunc() IdFunc {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.idFunc
+}
+
+// Declaring a stream of the table with one of StreamViewType* view types,
+// it's enabled on Create() or Migrate()
+func (t *Table) WithStream(viewType string) *Table {
+	t.declared.StreamSpecification = &dynamodb.StreamSpecification{
+		StreamEnabled:  aws.Bool(true),
+		StreamViewType: aws.String(viewType),
+	}
+	return t
+}
+
+// Getting the error of malformed index struct tags, the table can't be created if set
+func (t *Table) Err() error {
+	return t.err
+}
+
 func (t *Table) Name() string {
-	return *t.description.TableName
+	return *t.getDescription().TableName
 }
 
 func (t *Table) Description() dynamodb.TableDescription {
-	return *t.description
+	return *t.getDescription()
+}
+
+func (t *Table) getDescription() *dynamodb.TableDescription {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.description
+}
+
+func (t *Table) setDescription(description *dynamodb.TableDescription) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.description = description
 }
 
 func (t *Table) Create() error {
-	if out, err := t.session.CreateTable(&dynamodb.CreateTableInput{
-		TableName:            t.description.TableName,
-		AttributeDefinitions: t.description.AttributeDefinitions,
-		KeySchema:            t.description.KeySchema,
+	return t.CreateWithContext(context.Background())
+}
+
+// Same as Create() bound to the context
+func (t *Table) CreateWithContext(ctx context.Context) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	var (
+		lsis []*dynamodb.LocalSecondaryIndex
+		gsis []*dynamodb.GlobalSecondaryIndex
+	)
+
+	for _, lsi := range t.declared.LocalSecondaryIndexes {
+		lsis = append(lsis, &dynamodb.LocalSecondaryIndex{
+			IndexName:  lsi.IndexName,
+			KeySchema:  lsi.KeySchema,
+			Projection: lsi.Projection,
+		})
+	}
+
+	for _, gsi := range t.declared.GlobalSecondaryIndexes {
+		gsis = append(gsis, &dynamodb.GlobalSecondaryIndex{
+			IndexName:  gsi.IndexName,
+			KeySchema:  gsi.KeySchema,
+			Projection: gsi.Projection,
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+				ReadCapacityUnits:  gsi.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits: gsi.ProvisionedThroughput.WriteCapacityUnits,
+			},
+		})
+	}
+
+	session := t.getSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	if out, err := session.CreateTableWithContext(ctx, &dynamodb.CreateTableInput{
+		TableName:              t.declared.TableName,
+		AttributeDefinitions:   t.declared.AttributeDefinitions,
+		KeySchema:              t.declared.KeySchema,
+		LocalSecondaryIndexes:  lsis,
+		GlobalSecondaryIndexes: gsis,
+		StreamSpecification:    t.declared.StreamSpecification,
 		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
-			ReadCapacityUnits:  t.description.ProvisionedThroughput.ReadCapacityUnits,
-			WriteCapacityUnits: t.description.ProvisionedThroughput.WriteCapacityUnits,
+			ReadCapacityUnits:  t.declared.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: t.declared.ProvisionedThroughput.WriteCapacityUnits,
 		},
 	}); err != nil {
-		return err
+		// creating a table which is in use means it already exists
+		if isResourceInUse(err) {
+			return &Error{Kind: ErrTableExists, Err: err}
+		}
+
+		return classify(err)
 
 	} else {
-		t.description = out.TableDescription
+		t.setDescription(out.TableDescription)
 	}
 
 	return nil
 }
 
 func (t *Table) Delete() error {
-	if out, err := t.session.DeleteTable(&dynamodb.DeleteTableInput{
-		TableName: t.description.TableName,
+	return t.DeleteWithContext(context.Background())
+}
+
+// Same as Delete() bound to the context
+func (t *Table) DeleteWithContext(ctx context.Context) error {
+	session := t.getSession()
+	if session == nil {
+		return ErrorNoSession
+	}
+
+	if out, err := session.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput{
+		TableName: t.getDescription().TableName,
 	}); err != nil {
-		return err
+		return classify(err)
 
 	} else {
-		t.description = out.TableDescription
+		t.setDescription(out.TableDescription)
 	}
 
 	return nil
 }
 
-func (t *Table) keySchema() []*dynamodb.KeySchemaElement {
+// Getting an item by its hash and optional range key values
+func (t *Table) Get(hashKey interface{}, rangeKey ...interface{}) (Itemer, error) {
+	return t.GetWithContext(context.Background(), hashKey, rangeKey...)
+}
+
+// Same as Get() bound to the context
+func (t *Table) GetWithContext(ctx context.Context, hashKey interface{}, rangeKey ...interface{}) (Itemer, error) {
+	var r interface{}
+	if len(rangeKey) > 0 {
+		r = rangeKey[0]
+	}
+
+	return t.FindByKeyWithContext(ctx, hashKey, r, false)
+}
+
+// Getting an item by its primary key, rangeKey has to be nil for tables with HASH key only
+func (t *Table) FindByKey(hashKey, rangeKey interface{}, consistentRead bool) (Itemer, error) {
+	return t.FindByKeyWithContext(context.Background(), hashKey, rangeKey, consistentRead)
+}
+
+// Same as FindByKey() bound to the context
+func (t *Table) FindByKeyWithContext(ctx context.Context, hashKey, rangeKey interface{}, consistentRead bool) (Itemer, error) {
+	session := t.getSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	key, err := t.key(hashKey, rangeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := session.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      t.getDescription().TableName,
+		Key:            key,
+		ConsistentRead: aws.Bool(consistentRead),
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	if len(out.Item) == 0 || (!t.withDeleted && t.isDeleted(out.Item)) {
+		return nil, ErrorItemNotFound
+	}
+
+	return t.decodeItem(out.Item)
+}
+
+// Getting a copy of the table which doesn't exclude soft-deleted items, e.g.
+//
+//	item, err := tbl.WithDeleted().Get("1")
+func (t *Table) WithDeleted() *Table {
+	c := *t
+	c.withDeleted = true
+	return &c
+}
+
+// Getting attribute name of the item's `Deleted` field, empty if there is no such bool field
+func (t *Table) deletedAttributeName() string {
+	var item Itemer = t.NewItem()
+
+	f, ok := reflect.TypeOf(item.GetItem()).Elem().FieldByName("Deleted")
+	if !ok || f.Type.Kind() != reflect.Bool {
+		return ""
+	}
+
+	return strings.Split(f.Tag.Get(TagAttributeValue), ",")[0]
+}
+
+func (t *Table) isDeleted(av map[string]*dynamodb.AttributeValue) bool {
+	if name := t.deletedAttributeName(); name != "" && av[name] != nil {
+		return aws.BoolValue(av[name].BOOL)
+	}
+
+	return false
+}
+
+// Filter expression excluding soft-deleted items, empty for items without the `Deleted` field
+func (t *Table) notDeletedFilter(e *expression) string {
+	name := t.deletedAttributeName()
+	if name == "" || name == "-" {
+		return ""
+	}
+
+	return fmt.Sprintf("attribute_not_exists(%s) OR %s = %s", e.name(name), e.name(name), e.value(false))
+}
+
+// Joining non-empty filter expressions with AND
+func joinFilters(filters ...string) string {
+	var nonEmpty []string
+
+	for _, f := range filters {
+		if f != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+
+	if len(nonEmpty) == 1 {
+		return nonEmpty[0]
+	}
+
+	for n, f := range nonEmpty {
+		nonEmpty[n] = "(" + f + ")"
+	}
+
+	return strings.Join(nonEmpty, " AND ")
+}
+
+// Creating a new item bound to the table from a DynamoDB attribute map
+func (t *Table) decodeItem(av map[string]*dynamodb.AttributeValue) (Itemer, error) {
+	item := t.NewItem()
+
+	if err := item.Unmarshal(av); err != nil {
+		return nil, err
+	}
+
+	if err := afterLoad(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Building primary key attributes map out of hash and range key values
+func (t *Table) key(hashKey, rangeKey interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	var (
-		keys []*dynamodb.KeySchemaElement
-		item Itemer       = t.NewItem()
-		tp   reflect.Type = reflect.TypeOf(item.GetItem()).Elem()
+		key      map[string]*dynamodb.AttributeValue = make(map[string]*dynamodb.AttributeValue)
+		hasRange bool
 	)
 
+	for _, k := range t.keySchema() {
+		var value interface{}
+
+		switch *k.KeyType {
+		case KeyTypeHASH:
+			value = hashKey
+			break
+		case KeyTypeRANGE:
+			value = rangeKey
+			hasRange = true
+			break
+		}
+
+		if value == nil {
+			return nil, &MissingKeyError{AttributeName: *k.AttributeName, KeyType: *k.KeyType}
+		}
+
+		av, err := dynamodbattribute.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if isEmptyKeyValue(av) {
+			return nil, &MissingKeyError{AttributeName: *k.AttributeName, KeyType: *k.KeyType}
+		}
+
+		key[*k.AttributeName] = av
+	}
+
+	if rangeKey != nil && !hasRange {
+		return nil, ErrorUnexpectedRangeKey
+	}
+
+	return key, nil
+}
+
+// Getting hash and range key attribute names, rangeKey is empty for tables with HASH key only
+func (t *Table) keyNames() (hashKey, rangeKey string) {
+	for _, k := range t.keySchema() {
+		switch *k.KeyType {
+		case KeyTypeHASH:
+			hashKey = *k.AttributeName
+			break
+		case KeyTypeRANGE:
+			rangeKey = *k.AttributeName
+			break
+		}
+	}
+
+	return
+}
+
+func (t *Table) keySchema() []*dynamodb.KeySchemaElement {
+	var item Itemer = t.NewItem()
+
+	return getKeySchema(reflect.TypeOf(item.GetItem()).Elem())
+}
+
+// Helper function for getting table->item key schema from reflected Item type,
+// falls back to the default `Id` field as a HASH key
+func getKeySchema(tp reflect.Type) []*dynamodb.KeySchemaElement {
+	var keys []*dynamodb.KeySchemaElement
+
 	for i := 0; i < tp.NumField(); i++ {
 		var attributeName, keyType string
 
@@ -139,7 +515,6 @@ func (t *Table) keySchema() []*dynamodb.KeySchemaElement {
 				break
 			default:
 				continue
-				break
 			}
 
 		} else {
@@ -165,20 +540,26 @@ func (t *Table) keySchema() []*dynamodb.KeySchemaElement {
 	return keys
 }
 
-func (t *Table) localSecondaryIndexes() []*dynamodb.LocalSecondaryIndex {
+// Index declared with struct tags, shared between local and global secondary indexes
+type indexDeclaration struct {
+	name       string
+	keySchema  []*dynamodb.KeySchemaElement
+	projection *dynamodb.Projection
+	read       int
+	write      int
+}
+
+// Collecting index declarations from the `dynamodblsi` or `dynamodbgsi` tags of the item's fields
+func (t *Table) indexDeclarations(tag string) ([]*indexDeclaration, error) {
 	var (
-		keys   []*dynamodb.LocalSecondaryIndex
-		keyMap map[string]*dynamodb.LocalSecondaryIndex = make(map[string]*dynamodb.LocalSecondaryIndex)
-		item   Itemer                                   = t.NewItem()
-		tp     reflect.Type                             = reflect.TypeOf(item.GetItem()).Elem()
+		declarations []*indexDeclaration
+		keyMap       map[string]*indexDeclaration = make(map[string]*indexDeclaration)
+		item         Itemer                       = t.NewItem()
+		tp           reflect.Type                 = reflect.TypeOf(item.GetItem()).Elem()
 	)
 
 	for i := 0; i < tp.NumField(); i++ {
-		var (
-			attributeName, keyType     string
-			lsiName, lsiProjectionType string
-			// lsiReadCapacityUnits, lsiWriteCapacityUnits int
-		)
+		var attributeName string
 
 		if tp.Field(i).Anonymous && tp.Field(i).Type.Kind() != reflect.Struct {
 			continue
@@ -190,65 +571,245 @@ func (t *Table) localSecondaryIndexes() []*dynamodb.LocalSecondaryIndex {
 			continue
 		}
 
-		if dynamodblsiTagValue, ok := tp.Field(i).Tag.Lookup(TagLocalSecondaryIndex); ok {
-			var lsiType string
+		tagValue, ok := tp.Field(i).Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
 
-			lsiName, lsiType, _, _, lsiProjectionType = parseLsiTag(dynamodblsiTagValue)
-			switch lsiType {
-			case KeyTypeHASH, KeyTypeRANGE, KeyProjectionTypeINCLUDE:
-				keyType = lsiType
-				break
-			default:
-				continue
-				break
-			}
+		indexName, indexType, indexRead, indexWrite, indexProjectionType, err := parseIndexTag(tagValue)
+		if err != nil {
+			return nil, &IndexTagError{Field: tp.Field(i).Name, Tag: tag, Err: err}
+		}
 
-			// Make sure we have this index's key in the map
-			if _, ok := keyMap[lsiName]; !ok {
-				keyMap[lsiName] = &dynamodb.LocalSecondaryIndex{
-					IndexName: aws.String(lsiName),
-				}
+		// Make sure we have this index's key in the map
+		if _, ok := keyMap[indexName]; !ok {
+			keyMap[indexName] = &indexDeclaration{
+				name:       indexName,
+				projection: &dynamodb.Projection{},
 			}
+		}
+		index := keyMap[indexName]
 
-			// for HASH field only
-			switch lsiProjectionType {
-			case KeyProjectionTypeALL, KeyProjectionTypeINCLUDE, KeyProjectionTypeKEYSONLY:
-				keyMap[lsiName].Projection = &dynamodb.Projection{
-					ProjectionType: aws.String(lsiProjectionType),
-				}
-				break
-			}
+		// projection type can be set with any of the index's fields
+		if indexProjectionType != "" {
+			index.projection.ProjectionType = aws.String(indexProjectionType)
+		}
 
-			// For INCLUDE fiels only
-			if keyType == KeyProjectionTypeINCLUDE {
-				if keyMap[lsiName].Projection == nil {
-					keyMap[lsiName].Projection = &dynamodb.Projection{
-						ProjectionType: aws.String(KeyProjectionTypeINCLUDE),
-					}
-				}
+		if indexRead != 0 || indexWrite != 0 {
+			index.read, index.write = indexRead, indexWrite
+		}
 
-				keyMap[lsiName].Projection.NonKeyAttributes = append(keyMap[lsiName].Projection.NonKeyAttributes, aws.String(attributeName))
+		// For INCLUDE fiels only
+		if indexType == KeyProjectionTypeINCLUDE {
+			index.projection.NonKeyAttributes = append(index.projection.NonKeyAttributes, aws.String(attributeName))
+			continue
+		}
 
-			} else {
-				keyMap[lsiName].KeySchema = append(keyMap[lsiName].KeySchema, &dynamodb.KeySchemaElement{
-					AttributeName: aws.String(attributeName),
-					KeyType:       aws.String(keyType),
-				})
+		// HASH key always goes first
+		element := &dynamodb.KeySchemaElement{
+			AttributeName: aws.String(attributeName),
+			KeyType:       aws.String(indexType),
+		}
+		if indexType == KeyTypeHASH {
+			index.keySchema = append([]*dynamodb.KeySchemaElement{element}, index.keySchema...)
+		} else {
+			index.keySchema = append(index.keySchema, element)
+		}
+	}
+
+	for _, index := range keyMap {
+		declarations = append(declarations, index)
+	}
+
+	sort.Slice(declarations, func(i, j int) bool {
+		return declarations[i].name < declarations[j].name
+	})
+
+	return declarations, nil
+}
 
+// Making sure the index has at most one HASH and RANGE key and a consistent projection,
+// the projection is ALL when not set
+func (d *indexDeclaration) validate() (hashName, rangeName string, err error) {
+	for _, k := range d.keySchema {
+		switch *k.KeyType {
+		case KeyTypeHASH:
+			if hashName != "" {
+				return "", "", &IndexError{IndexName: d.name, Reason: "more than one HASH key"}
 			}
+			hashName = *k.AttributeName
+		case KeyTypeRANGE:
+			if rangeName != "" {
+				return "", "", &IndexError{IndexName: d.name, Reason: "more than one RANGE key"}
+			}
+			rangeName = *k.AttributeName
+		}
+	}
 
+	if d.projection.ProjectionType == nil {
+		if len(d.projection.NonKeyAttributes) > 0 {
+			d.projection.ProjectionType = aws.String(KeyProjectionTypeINCLUDE)
 		} else {
+			d.projection.ProjectionType = aws.String(KeyProjectionTypeALL)
+		}
+	}
 
-			continue
+	if len(d.projection.NonKeyAttributes) > 0 && *d.projection.ProjectionType != KeyProjectionTypeINCLUDE {
+		return "", "", &IndexError{IndexName: d.name, Reason: "INCLUDE attributes require INCLUDE projection type"}
+	}
+
+	return hashName, rangeName, nil
+}
+
+// Local secondary indexes declared with `dynamodblsi` tags,
+// the table's HASH key is used when the index has no HASH field
+func (t *Table) localSecondaryIndexes() ([]*dynamodb.LocalSecondaryIndex, error) {
+	var (
+		lsis          []*dynamodb.LocalSecondaryIndex
+		tableHashName string
+	)
+
+	tableHashName, _ = t.keyNames()
+
+	declarations, err := t.indexDeclarations(TagLocalSecondaryIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range declarations {
+		hashName, rangeName, err := d.validate()
+		if err != nil {
+			return nil, err
 		}
 
+		if rangeName == "" {
+			return nil, &IndexError{IndexName: d.name, Reason: "RANGE key is required"}
+		}
+
+		if hashName == "" {
+			d.keySchema = append([]*dynamodb.KeySchemaElement{{
+				AttributeName: aws.String(tableHashName),
+				KeyType:       aws.String(KeyTypeHASH),
+			}}, d.keySchema...)
+
+		} else if hashName != tableHashName {
+			return nil, &IndexError{IndexName: d.name, Reason: fmt.Sprintf("HASH key has to be the table's HASH key '%s'", tableHashName)}
+		}
+
+		lsis = append(lsis, &dynamodb.LocalSecondaryIndex{
+			IndexName:  aws.String(d.name),
+			KeySchema:  d.keySchema,
+			Projection: d.projection,
+		})
 	}
 
-	for _, v := range keyMap {
-		keys = append(keys, v)
+	return lsis, nil
+}
+
+// Global secondary indexes declared with `dynamodbgsi` tags,
+// read and write capacity units fall back to the table's ones when not set
+func (t *Table) globalSecondaryIndexes() ([]*dynamodb.GlobalSecondaryIndex, error) {
+	var gsis []*dynamodb.GlobalSecondaryIndex
+
+	declarations, err := t.indexDeclarations(TagGlobalSecondaryIndex)
+	if err != nil {
+		return nil, err
 	}
 
-	return keys
+	for _, d := range declarations {
+		hashName, _, err := d.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		if hashName == "" {
+			return nil, &IndexError{IndexName: d.name, Reason: "HASH key is required"}
+		}
+
+		if d.read < 0 || d.write < 0 {
+			return nil, &IndexError{IndexName: d.name, Reason: "capacity units can not be negative"}
+		}
+
+		if (d.read > 0) != (d.write > 0) {
+			return nil, &IndexError{IndexName: d.name, Reason: "both read and write capacity units have to be set"}
+		}
+
+		throughput := &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  t.description.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: t.description.ProvisionedThroughput.WriteCapacityUnits,
+		}
+		if d.read > 0 && d.write > 0 {
+			throughput.ReadCapacityUnits = aws.Int64(int64(d.read))
+			throughput.WriteCapacityUnits = aws.Int64(int64(d.write))
+		}
+
+		gsis = append(gsis, &dynamodb.GlobalSecondaryIndex{
+			IndexName:             aws.String(d.name),
+			KeySchema:             d.keySchema,
+			Projection:            d.projection,
+			ProvisionedThroughput: throughput,
+		})
+	}
+
+	return gsis, nil
+}
+
+// Converting local secondary indexes into the table description's ones
+func localSecondaryIndexDescriptions(lsis []*dynamodb.LocalSecondaryIndex) []*dynamodb.LocalSecondaryIndexDescription {
+	var descriptions []*dynamodb.LocalSecondaryIndexDescription
+
+	for _, lsi := range lsis {
+		descriptions = append(descriptions, &dynamodb.LocalSecondaryIndexDescription{
+			IndexName:  lsi.IndexName,
+			KeySchema:  lsi.KeySchema,
+			Projection: lsi.Projection,
+		})
+	}
+
+	return descriptions
+}
+
+// Converting global secondary indexes into the table description's ones
+func globalSecondaryIndexDescriptions(gsis []*dynamodb.GlobalSecondaryIndex) []*dynamodb.GlobalSecondaryIndexDescription {
+	var descriptions []*dynamodb.GlobalSecondaryIndexDescription
+
+	for _, gsi := range gsis {
+		descriptions = append(descriptions, &dynamodb.GlobalSecondaryIndexDescription{
+			IndexName:  gsi.IndexName,
+			KeySchema:  gsi.KeySchema,
+			Projection: gsi.Projection,
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+				ReadCapacityUnits:  gsi.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits: gsi.ProvisionedThroughput.WriteCapacityUnits,
+			},
+		})
+	}
+
+	return descriptions
+}
+
+// Getting attribute name of the field tagged with `dynamodbttl`,
+// the field is expected to hold Unix epoch seconds
+func (t *Table) timeToLiveAttribute() string {
+	var (
+		item Itemer       = t.NewItem()
+		tp   reflect.Type = reflect.TypeOf(item.GetItem()).Elem()
+	)
+
+	for i := 0; i < tp.NumField(); i++ {
+		if _, ok := tp.Field(i).Tag.Lookup(TagTimeToLive); !ok {
+			continue
+		}
+
+		attributeName := strings.Split(tp.Field(i).Tag.Get(TagAttributeValue), ",")[0]
+		if attributeName == "" || attributeName == "-" {
+			attributeName = tp.Field(i).Name
+		}
+
+		return attributeName
+	}
+
+	return ""
 }
 
 // For table creation process
@@ -270,7 +831,10 @@ func (t *Table) attributeDefinitions() []*dynamodb.AttributeDefinition {
 
 // Helper function for getting table->item Attributes' Definition from reflected Item type
 func getAttributeDefinitionMap(t reflect.Type) map[string]*dynamodb.AttributeDefinition {
-	var adm map[string]*dynamodb.AttributeDefinition = make(map[string]*dynamodb.AttributeDefinition)
+	var (
+		adm           map[string]*dynamodb.AttributeDefinition = make(map[string]*dynamodb.AttributeDefinition)
+		hasPrimaryKey bool
+	)
 
 	for i := 0; i < t.NumField(); i++ {
 		var (
@@ -279,15 +843,21 @@ func getAttributeDefinitionMap(t reflect.Type) map[string]*dynamodb.AttributeDef
 		)
 
 		f := t.Field(i)
-		if _, ok := f.Tag.Lookup(TagPrimaryKey); ok {
+		if tagValue, ok := f.Tag.Lookup(TagPrimaryKey); ok {
 			skip = false
+
+			switch strings.ToUpper(tagValue) {
+			case KeyTypeHASH, KeyTypeRANGE:
+				hasPrimaryKey = true
+			}
 		}
 
-		if tagValue, ok := f.Tag.Lookup(TagLocalSecondaryIndex); ok {
-			if _, indexType, _, _, _ := parseLsiTag(tagValue); indexType == KeyProjectionTypeINCLUDE {
-				skip = true
-			} else {
-				skip = false
+		// INCLUDE attributes are not part of any key, so they don't need a definition
+		for _, tag := range []string{TagLocalSecondaryIndex, TagGlobalSecondaryIndex} {
+			if tagValue, ok := f.Tag.Lookup(tag); ok {
+				if _, indexType, _, _, _, err := parseIndexTag(tagValue); err == nil && indexType != KeyProjectionTypeINCLUDE {
+					skip = false
+				}
 			}
 		}
 
@@ -307,8 +877,8 @@ func getAttributeDefinitionMap(t reflect.Type) map[string]*dynamodb.AttributeDef
 		}
 	}
 
-	// setting default `id` field
-	if len(adm) == 0 {
+	// setting default `id` field, which is the HASH key when there is no `dynamodbpk` tag
+	if !hasPrimaryKey {
 		f, _ := t.FieldByName("Id")
 		attributeName, attributeType := getFieldAttributeNameAndType(f, adm)
 
@@ -378,7 +948,6 @@ func getFieldAttributeNameAndType(f reflect.StructField, adm map[string]*dynamod
 				}
 				// Continue here to avoid having "-" fields inherited form nested struct field name
 				return
-				break
 			}
 			break
 		default:
@@ -390,38 +959,56 @@ func getFieldAttributeNameAndType(f reflect.StructField, adm map[string]*dynamod
 	return
 }
 
-// Examples
-// 	`dynamodblsi:"IdDateLsi,HASH,3,3,ALL"`
+// Parsing `dynamodblsi` and `dynamodbgsi` tag values, examples
+//
+//	`dynamodblsi:"IdDateLsi,HASH,3,3,ALL"`
 //	`dynamodblsi:"IdDateLsi,RANGE"`
 //	`dynamodblsi:"IdDateLsi,INCLUDE"`
-func parseLsiTag(s string) (indexName, indexType string, indexRead, indexWrite int, indexProjectionType string) {
+//	`dynamodbgsi:"NameGsi,HASH,10,5,INCLUDE"`
+func parseIndexTag(s string) (indexName, indexType string, indexRead, indexWrite int, indexProjectionType string, err error) {
 	slice := strings.Split(s, ",")
 
-	if len(slice) >= 0 {
-		indexName = slice[0]
+	if len(slice) > 5 {
+		return "", "", 0, 0, "", fmt.Errorf("too many values in '%s'", s)
 	}
 
-	if len(slice) >= 1 {
-		switch slice[1] {
+	indexName = slice[0]
+	if indexName == "" {
+		return "", "", 0, 0, "", fmt.Errorf("index name is missing in '%s'", s)
+	}
+
+	if len(slice) >= 2 {
+		switch strings.ToUpper(slice[1]) {
 		case KeyTypeHASH, KeyTypeRANGE, KeyProjectionTypeINCLUDE:
-			indexType = slice[1]
+			indexType = strings.ToUpper(slice[1])
 		}
 	}
 
+	if indexType == "" {
+		return "", "", 0, 0, "", fmt.Errorf("index type has to be one of HASH, RANGE or INCLUDE in '%s'", s)
+	}
+
+	if len(slice) == 3 {
+		return "", "", 0, 0, "", fmt.Errorf("both read and write capacity units are required in '%s'", s)
+	}
+
 	if len(slice) >= 4 {
-		v := slice[2]
-		if i, err := strconv.Atoi(v); err != nil {
-			indexRead = i
+		if indexRead, err = strconv.Atoi(slice[2]); err != nil {
+			return "", "", 0, 0, "", fmt.Errorf("read capacity units is not a number in '%s'", s)
 		}
 
-		v = slice[3]
-		if i, err := strconv.Atoi(v); err != nil {
-			indexWrite = i
+		if indexWrite, err = strconv.Atoi(slice[3]); err != nil {
+			return "", "", 0, 0, "", fmt.Errorf("write capacity units is not a number in '%s'", s)
 		}
 	}
 
 	if len(slice) == 5 {
-		indexProjectionType = slice[5]
+		switch strings.ToUpper(slice[4]) {
+		case KeyProjectionTypeALL, KeyProjectionTypeINCLUDE, KeyProjectionTypeKEYSONLY:
+			indexProjectionType = strings.ToUpper(slice[4])
+		default:
+			return "", "", 0, 0, "", fmt.Errorf("projection type has to be one of ALL, KEYS_ONLY or INCLUDE in '%s'", s)
+		}
 	}
 
 	return
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/stretchr/testify/assert"
 )
@@ -230,7 +229,8 @@ func TestLocalSecondaryIndexes(t *testing.T) {
 		return &User{}
 	})
 
-	lsi := tbl.localSecondaryIndexes()
+	lsi, err := tbl.localSecondaryIndexes()
+	assert.Nil(t, err)
 	assert.Len(t, lsi, 1)
 	assert.Contains(t, lsi, &dynamodb.LocalSecondaryIndex{
 		IndexName: aws.String("IdDateLsi"),
@@ -293,10 +293,381 @@ func TestCreate(t *testing.T) {
 	}).WithSession(d.session)
 
 	if err := tbl.Create(); err != nil {
-		assert.Nil(t, err, err.(awserr.Error).Error())
+		assert.Nil(t, err, err.Error())
 	}
 
 	if err := tbl.Delete(); err != nil {
-		assert.Nil(t, err, err.(awserr.Error).Error())
+		assert.Nil(t, err, err.Error())
 	}
 }
+
+func TestDescriptionConcurrency(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	done := make(chan bool)
+	go func() {
+		for n := 0; n < 100; n++ {
+			tbl.setDescription(tbl.declared)
+		}
+		close(done)
+	}()
+
+	for n := 0; n < 100; n++ {
+		assert.Equal(t, "users", tbl.Name())
+	}
+	<-done
+}
+
+func TestKey(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string    `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date time.Time `json:"time" dynamodbav:"time" dynamodbpk:"RANGE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	{
+		key, err := tbl.key("1", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Nil(t, err)
+		assert.Len(t, key, 2)
+		assert.Equal(t, "1", *key["uuid"].S)
+		assert.Equal(t, "2017-01-01T00:00:00Z", *key["time"].S)
+	}
+
+	{
+		key, err := tbl.key("1", nil)
+		assert.Nil(t, key)
+		assert.Equal(t, &MissingKeyError{AttributeName: "time", KeyType: KeyTypeRANGE}, err)
+	}
+
+	{
+		key, err := tbl.key("", time.Now())
+		assert.Nil(t, key)
+		assert.Equal(t, &MissingKeyError{AttributeName: "uuid", KeyType: KeyTypeHASH}, err)
+	}
+}
+
+func TestKeyUnexpectedRange(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	key, err := tbl.key("1", "2")
+	assert.Nil(t, key)
+	assert.Equal(t, ErrorUnexpectedRangeKey, err)
+}
+
+func TestGet(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		UUID string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u := tbl.NewItem().(*User)
+	u.UUID = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	{
+		item, err := tbl.Get("1")
+		assert.Nil(t, err)
+		if assert.IsType(t, &User{}, item) {
+			assert.Equal(t, "Roman", item.(*User).Name)
+			assert.Equal(t, "users", item.(*User).tableName)
+			assert.Equal(t, d.session, item.(*User).session)
+		}
+	}
+
+	{
+		item, err := tbl.FindByKey("1", nil, true)
+		assert.Nil(t, err)
+		assert.IsType(t, &User{}, item)
+	}
+
+	{
+		item, err := tbl.Get("2")
+		assert.Nil(t, item)
+		assert.Equal(t, ErrorItemNotFound, err)
+	}
+}
+
+func TestParseIndexTag(t *testing.T) {
+	{
+		name, tp, r, w, projection, err := parseIndexTag("IdDateLsi,HASH,3,4,KEYS_ONLY")
+		assert.Nil(t, err)
+		assert.Equal(t, "IdDateLsi", name)
+		assert.Equal(t, KeyTypeHASH, tp)
+		assert.Equal(t, 3, r)
+		assert.Equal(t, 4, w)
+		assert.Equal(t, KeyProjectionTypeKEYSONLY, projection)
+	}
+
+	{
+		name, tp, _, _, projection, err := parseIndexTag("IdDateLsi,range")
+		assert.Nil(t, err)
+		assert.Equal(t, "IdDateLsi", name)
+		assert.Equal(t, KeyTypeRANGE, tp)
+		assert.Equal(t, "", projection)
+	}
+
+	for _, tag := range []string{
+		"",
+		"IdDateLsi",
+		",HASH",
+		"IdDateLsi,KEY",
+		"IdDateLsi,HASH,3",
+		"IdDateLsi,HASH,a,3",
+		"IdDateLsi,HASH,3,b",
+		"IdDateLsi,HASH,3,3,SOME",
+		"IdDateLsi,HASH,3,3,ALL,more",
+	} {
+		_, _, _, _, _, err := parseIndexTag(tag)
+		assert.NotNil(t, err, tag)
+	}
+}
+
+func TestLocalSecondaryIndexesDescription(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string    `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date  time.Time `json:"time" dynamodbav:"time" dynamodbpk:"RANGE"`
+		Count int       `json:"count" dynamodbav:"count" dynamodblsi:"CountLsi,RANGE"`
+		Name  string    `json:"name" dynamodbav:"name" dynamodblsi:"NameLsi,RANGE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	lsi := tbl.Description().LocalSecondaryIndexes
+	assert.Len(t, lsi, 2)
+	assert.Equal(t, &dynamodb.LocalSecondaryIndexDescription{
+		IndexName: aws.String("CountLsi"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("uuid"), KeyType: aws.String(KeyTypeHASH)},
+			{AttributeName: aws.String("count"), KeyType: aws.String(KeyTypeRANGE)},
+		},
+		Projection: &dynamodb.Projection{
+			ProjectionType: aws.String(KeyProjectionTypeALL),
+		},
+	}, lsi[0])
+	assert.Equal(t, "NameLsi", *lsi[1].IndexName)
+
+	assert.Len(t, tbl.Description().AttributeDefinitions, 4)
+}
+
+func TestLocalSecondaryIndexesMalformed(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Count int    `json:"count" dynamodbav:"count" dynamodblsi:"CountLsi,RANGE,x,1"`
+	}
+
+	type Account struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Name  string `json:"name" dynamodbav:"name" dynamodblsi:"NameLsi,HASH"`
+		Count int    `json:"count" dynamodbav:"count" dynamodblsi:"NameLsi,RANGE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+	assert.IsType(t, &IndexTagError{}, tbl.Err())
+	assert.Equal(t, tbl.Err(), tbl.Create())
+
+	tbl = NewTable("accounts", func() Itemer {
+		return &Account{}
+	})
+	assert.Equal(t, &IndexError{IndexName: "NameLsi", Reason: "HASH key has to be the table's HASH key 'uuid'"}, tbl.Err())
+	assert.Equal(t, tbl.Err(), tbl.Create())
+}
+
+func TestCreateWithLocalSecondaryIndexes(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string    `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Date  time.Time `json:"time" dynamodbav:"time" dynamodbpk:"RANGE"`
+		Count int       `json:"count" dynamodbav:"count" dynamodblsi:"CountLsi,RANGE"`
+		Name  string    `json:"name" dynamodbav:"name" dynamodblsi:"CountLsi,INCLUDE"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	lsi := tbl.Description().LocalSecondaryIndexes
+	if assert.Len(t, lsi, 1) {
+		assert.Equal(t, "CountLsi", *lsi[0].IndexName)
+		assert.Equal(t, KeyProjectionTypeINCLUDE, *lsi[0].Projection.ProjectionType)
+	}
+}
+
+func TestGlobalSecondaryIndexes(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		UUID  string `json:"uuid" dynamodbav:"uuid" dynamodbpk:"HASH"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,10,2,INCLUDE"`
+		Name  string `json:"name" dynamodbav:"name" dynamodbgsi:"EmailGsi,INCLUDE"`
+		Group string `json:"group" dynamodbav:"group" dynamodbgsi:"GroupGsi,HASH"`
+		Count int    `json:"count" dynamodbav:"count" dynamodbgsi:"GroupGsi,RANGE"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	gsi := tbl.Description().GlobalSecondaryIndexes
+	assert.Len(t, gsi, 2)
+	assert.Equal(t, &dynamodb.GlobalSecondaryIndexDescription{
+		IndexName: aws.String("EmailGsi"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("email"), KeyType: aws.String(KeyTypeHASH)},
+		},
+		Projection: &dynamodb.Projection{
+			ProjectionType:   aws.String(KeyProjectionTypeINCLUDE),
+			NonKeyAttributes: []*string{aws.String("name")},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  aws.Int64(10),
+			WriteCapacityUnits: aws.Int64(2),
+		},
+	}, gsi[0])
+	assert.Equal(t, &dynamodb.GlobalSecondaryIndexDescription{
+		IndexName: aws.String("GroupGsi"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("group"), KeyType: aws.String(KeyTypeHASH)},
+			{AttributeName: aws.String("count"), KeyType: aws.String(KeyTypeRANGE)},
+		},
+		Projection: &dynamodb.Projection{
+			ProjectionType: aws.String(KeyProjectionTypeALL),
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		},
+	}, gsi[1])
+
+	ad := tbl.Description().AttributeDefinitions
+	assert.Len(t, ad, 4)
+	assert.Contains(t, ad, &dynamodb.AttributeDefinition{
+		AttributeName: aws.String("email"),
+		AttributeType: aws.String("S"),
+	})
+	assert.Contains(t, ad, &dynamodb.AttributeDefinition{
+		AttributeName: aws.String("count"),
+		AttributeType: aws.String("N"),
+	})
+	assert.NotContains(t, ad, &dynamodb.AttributeDefinition{
+		AttributeName: aws.String("name"),
+		AttributeType: aws.String("S"),
+	})
+}
+
+func TestGlobalSecondaryIndexesMalformed(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Count int `json:"count" dynamodbav:"count" dynamodbgsi:"CountGsi,RANGE"`
+	}
+
+	type Account struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,5,0"`
+	}
+
+	type Post struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Title string `json:"title" dynamodbav:"title" dynamodbgsi:"TitleGsi,HASH,-1,-1"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+	assert.Equal(t, &IndexError{IndexName: "CountGsi", Reason: "HASH key is required"}, tbl.Err())
+	assert.Equal(t, tbl.Err(), tbl.Create())
+
+	assert.Equal(t, &IndexError{IndexName: "EmailGsi", Reason: "both read and write capacity units have to be set"}, NewTable("accounts", func() Itemer {
+		return &Account{}
+	}).Err())
+
+	assert.Equal(t, &IndexError{IndexName: "TitleGsi", Reason: "capacity units can not be negative"}, NewTable("posts", func() Itemer {
+		return &Post{}
+	}).Err())
+}
+
+func TestCreateWithGlobalSecondaryIndexes(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH,3,3,KEYS_ONLY"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	}).WithSession(d.session)
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	gsi := tbl.Description().GlobalSecondaryIndexes
+	if assert.Len(t, gsi, 1) {
+		assert.Equal(t, "EmailGsi", *gsi[0].IndexName)
+		assert.Equal(t, int64(3), *gsi[0].ProvisionedThroughput.ReadCapacityUnits)
+	}
+}
+
+func TestAttributeDefinitionsDefaultIdWithIndexes(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Email string `json:"email" dynamodbav:"email" dynamodbgsi:"EmailGsi,HASH"`
+	}
+
+	tbl := NewTable("users", func() Itemer {
+		return &User{}
+	})
+
+	ad := tbl.Description().AttributeDefinitions
+	assert.Len(t, ad, 2)
+	assert.Contains(t, ad, &dynamodb.AttributeDefinition{
+		AttributeName: aws.String("id"),
+		AttributeType: aws.String("S"),
+	})
+}
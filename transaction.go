@@ -0,0 +1,629 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// DynamoDB limit of operations in a single transaction
+	TransactLimit int = 100
+)
+
+var (
+	ErrorTransactionTooLarge error = fmt.Errorf("Transaction can not have more than %d operations", TransactLimit)
+	ErrorConditionFailed     error = ErrConditionFailed
+)
+
+// Returned by Commit() when DynamoDB canceled the transaction,
+// holds a reason for every operation in the order they were added
+type TransactionCanceledError struct {
+	Reasons []*CancellationReason
+	Err     error
+}
+
+func (e *TransactionCanceledError) Error() string {
+	var failed []string
+
+	for _, r := range e.Reasons {
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("#%d %s: %s", r.Index, r.Operation, r.Err.Error()))
+		}
+	}
+
+	return "Transaction canceled: " + strings.Join(failed, "; ")
+}
+
+func (e *TransactionCanceledError) Is(target error) bool {
+	return target == ErrTransactionCanceled
+}
+
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.Err
+}
+
+// Why a single operation of a canceled transaction failed, Err is nil
+// for the operations which did not cause the cancellation
+type CancellationReason struct {
+	Index     int
+	Operation string
+	TableName string
+	Item      Itemer
+	Code      string
+	Message   string
+	Err       error
+}
+
+// All-or-nothing writes across registered tables, e.g.
+//
+//	err := d.Transaction().
+//		Put(order).
+//		UpdateIf(product, "#stock >= ?", order.Quantity).
+//		Check(user, "attribute_exists(#id)").
+//		WithToken(order.Id).
+//		Commit()
+//
+// Put and Update run BeforeSave hooks, validation and stamp timestamps right away,
+// the items are brought back to their previous state when the transaction isn't committed.
+type Transaction struct {
+	dytona *Dytona
+
+	operations []*transactOperation
+	token      string
+	err        error
+}
+
+type transactOperation struct {
+	name      string
+	item      *Item
+	write     *dynamodb.TransactWriteItem
+	condition bool
+
+	// version field, incremented on commit
+	versionValue reflect.Value
+	versioned    bool
+
+	// update time written by the operation and restoring the item if it's not committed
+	updatedAt time.Time
+	restore   func()
+}
+
+// Starting a transaction
+func (d *Dytona) Transaction() *Transaction {
+	return &Transaction{dytona: d}
+}
+
+// Putting the whole item, checking its version if it has one
+func (tx *Transaction) Put(item Itemer) *Transaction {
+	i := item.base()
+	if tx.check(i) {
+		return tx
+	}
+
+	restore := i.snapshot()
+
+	if tx.fail(beforeSave(i.item)) || tx.fail(i.validate()) {
+		restore()
+		return tx
+	}
+
+	i.touch(time.Now().UTC())
+
+	input, versionValue, versioned, err := i.putInput()
+	if tx.fail(err) {
+		restore()
+		return tx
+	}
+
+	tx.operations = append(tx.operations, &transactOperation{
+		name: "Put",
+		item: i,
+		write: &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+			TableName:                 input.TableName,
+			Item:                      input.Item,
+			ConditionExpression:       input.ConditionExpression,
+			ExpressionAttributeNames:  input.ExpressionAttributeNames,
+			ExpressionAttributeValues: input.ExpressionAttributeValues,
+		}},
+		versionValue: versionValue,
+		versioned:    versioned,
+		restore:      restore,
+	})
+
+	return tx
+}
+
+// Updating only the changed attributes of an existing item, items with nothing to update are skipped
+func (tx *Transaction) Update(item Itemer) *Transaction {
+	return tx.UpdateIf(item, "")
+}
+
+// Updating only the changed attributes of an existing item if the condition is met,
+// `#attribute` are attribute names and `?` are values in order
+func (tx *Transaction) UpdateIf(item Itemer, condition string, values ...interface{}) *Transaction {
+	i := item.base()
+	if tx.check(i) {
+		return tx
+	}
+
+	restore := i.snapshot()
+
+	if tx.fail(beforeSave(i.item)) || tx.fail(i.validate()) {
+		restore()
+		return tx
+	}
+
+	now := time.Now().UTC()
+
+	input, err := i.updateInput(condition, values, now)
+	if tx.fail(err) || input == nil {
+		restore()
+		return tx
+	}
+
+	versionValue, _, versioned, _ := i.versionField()
+
+	tx.operations = append(tx.operations, &transactOperation{
+		name: "Update",
+		item: i,
+		write: &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                 input.TableName,
+			Key:                       input.Key,
+			UpdateExpression:          input.UpdateExpression,
+			ConditionExpression:       input.ConditionExpression,
+			ExpressionAttributeNames:  input.ExpressionAttributeNames,
+			ExpressionAttributeValues: input.ExpressionAttributeValues,
+		}},
+		condition:    condition != "",
+		versionValue: versionValue,
+		versioned:    versioned,
+		updatedAt:    now,
+		restore:      restore,
+	})
+
+	return tx
+}
+
+// Deleting the item, checking its version if it has one
+func (tx *Transaction) Delete(item Itemer) *Transaction {
+	i := item.base()
+	if tx.check(i) {
+		return tx
+	}
+
+	if tx.fail(beforeDelete(i.item)) {
+		return tx
+	}
+
+	input, versionValue, versioned, err := i.deleteInput()
+	if tx.fail(err) {
+		return tx
+	}
+
+	tx.operations = append(tx.operations, &transactOperation{
+		name: "Delete",
+		item: i,
+		write: &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName:                 input.TableName,
+			Key:                       input.Key,
+			ConditionExpression:       input.ConditionExpression,
+			ExpressionAttributeNames:  input.ExpressionAttributeNames,
+			ExpressionAttributeValues: input.ExpressionAttributeValues,
+		}},
+		versionValue: versionValue,
+		versioned:    versioned,
+	})
+
+	return tx
+}
+
+// Checking a condition on the stored item without writing it
+func (tx *Transaction) Check(item Itemer, condition string, values ...interface{}) *Transaction {
+	i := item.base()
+	if tx.check(i) {
+		return tx
+	}
+
+	av, err := i.Marshal()
+	if tx.fail(err) {
+		return tx
+	}
+
+	key, err := i.key(av)
+	if tx.fail(err) {
+		return tx
+	}
+
+	e := newExpression()
+	expression := e.parse(condition, values)
+	if tx.fail(e.err) {
+		return tx
+	}
+
+	tx.operations = append(tx.operations, &transactOperation{
+		name: "ConditionCheck",
+		item: i,
+		write: &dynamodb.TransactWriteItem{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:                 aws.String(i.tableName),
+			Key:                       key,
+			ConditionExpression:       aws.String(expression),
+			ExpressionAttributeNames:  e.attributeNames(),
+			ExpressionAttributeValues: e.attributeValues(),
+		}},
+		condition: true,
+	})
+
+	return tx
+}
+
+// Setting an idempotency token, retrying a committed transaction
+// with the same token within 10 minutes has no effect
+func (tx *Transaction) WithToken(token string) *Transaction {
+	tx.token = token
+	return tx
+}
+
+// Writing all the operations at once, *TransactionCanceledError is returned
+// when any of the conditions failed and nothing was written
+func (tx *Transaction) Commit() error {
+	return tx.CommitWithContext(context.Background())
+}
+
+// Same as Commit() bound to the context
+func (tx *Transaction) CommitWithContext(ctx context.Context) error {
+	input, err := tx.input()
+	if err != nil {
+		tx.restore()
+		return err
+	}
+
+	if input == nil {
+		return nil
+	}
+
+	// the session could have been closed since the input was built
+	session := tx.dytona.GetSession()
+	if session == nil {
+		tx.restore()
+		return ErrorNoSession
+	}
+
+	if _, err := session.TransactWriteItemsWithContext(ctx, input); err != nil {
+		tx.restore()
+		return tx.canceled(ctx, session, err)
+	}
+
+	var commitErr error
+
+	// every item is updated even if a hook of another one fails
+	for _, op := range tx.operations {
+		if err := op.committed(); err != nil && commitErr == nil {
+			commitErr = err
+		}
+	}
+
+	return commitErr
+}
+
+func (tx *Transaction) input() (*dynamodb.TransactWriteItemsInput, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+
+	if tx.dytona.GetSession() == nil {
+		return nil, ErrorNoSession
+	}
+
+	// nothing was changed by the updates
+	if len(tx.operations) == 0 {
+		return nil, nil
+	}
+
+	if len(tx.operations) > TransactLimit {
+		return nil, ErrorTransactionTooLarge
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{}
+	for _, op := range tx.operations {
+		input.TransactItems = append(input.TransactItems, op.write)
+	}
+
+	if tx.token != "" {
+		input.ClientRequestToken = aws.String(tx.token)
+	}
+
+	return input, nil
+}
+
+// Bringing the items of not committed operations back to their state before the transaction
+func (tx *Transaction) restore() {
+	for _, op := range tx.operations {
+		if op.restore != nil {
+			op.restore()
+		}
+	}
+}
+
+// Making sure the item is linked to itself and a table, returns true if the transaction has failed
+func (tx *Transaction) check(i *Item) bool {
+	if tx.err != nil {
+		return true
+	}
+
+	switch {
+	case i.item == nil:
+		return tx.fail(ErrorItemNotSet)
+	case i.tableName == "":
+		return tx.fail(ErrorNoTableName)
+	}
+
+	return false
+}
+
+// Keeping the first error, Commit() returns it without writing anything
+func (tx *Transaction) fail(err error) bool {
+	if err != nil && tx.err == nil {
+		tx.err = err
+	}
+
+	return tx.err != nil
+}
+
+// Matching cancellation reasons with the operations
+func (tx *Transaction) canceled(ctx context.Context, session *dynamodb.DynamoDB, err error) error {
+	codes, messages, ok := cancellationReasons(err)
+	if !ok || len(codes) != len(tx.operations) {
+		return classify(err)
+	}
+
+	reasons := make([]*CancellationReason, len(codes))
+	for n, code := range codes {
+		op := tx.operations[n]
+
+		reasons[n] = &CancellationReason{
+			Index:     n,
+			Operation: op.name,
+			TableName: op.item.tableName,
+			Item:      op.item.item,
+			Code:      code,
+			Message:   messages[n],
+			Err:       op.reasonError(ctx, session, code, messages[n]),
+		}
+	}
+
+	return &TransactionCanceledError{Reasons: reasons, Err: err}
+}
+
+// Getting cancellation reason codes and messages of a canceled transaction, in the order of operations
+func cancellationReasons(err error) (codes []string, messages []string, ok bool) {
+	var canceled *dynamodb.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, r := range canceled.CancellationReasons {
+			codes = append(codes, aws.StringValue(r.Code))
+			messages = append(messages, aws.StringValue(r.Message))
+		}
+
+		return codes, messages, true
+	}
+
+	return nil, nil, false
+}
+
+// Translating a cancellation reason code into the same errors the single item operations return
+func (op *transactOperation) reasonError(ctx context.Context, session *dynamodb.DynamoDB, code, message string) error {
+	if code != "ConditionalCheckFailed" {
+		return cancellationError(code, message)
+	}
+
+	// the condition is checked along with the version one
+	if op.versioned && op.condition {
+		if err := op.item.versionConflict(ctx, session, cancellationError(code, message)); err != nil {
+			return err
+		}
+	}
+
+	if op.condition {
+		return ErrorConditionFailed
+	}
+
+	if op.versioned {
+		return &VersionConflictError{TableName: op.item.tableName, Version: op.versionValue.Int(), Err: cancellationError(code, message)}
+	}
+
+	if op.name == "Update" {
+		return ErrorItemNotFound
+	}
+
+	return ErrorConditionFailed
+}
+
+func cancellationError(code, message string) error {
+	switch {
+	case code == "" || code == "None":
+		return nil
+	case message == "":
+		return errors.New(code)
+	}
+
+	return fmt.Errorf("%s: %s", code, message)
+}
+
+// Updating the item the same way a successful single item operation does
+func (op *transactOperation) committed() error {
+	i := op.item
+
+	switch op.name {
+	case "Put", "Update":
+		if op.versioned {
+			op.versionValue.SetInt(op.versionValue.Int() + 1)
+		}
+
+		// the updated state is not returned by transactions
+		if rValue, _, found := i.get("UpdatedAt"); op.name == "Update" && found && rValue.Type() == timeType {
+			rValue.Set(reflect.ValueOf(op.updatedAt))
+		}
+
+		av, err := i.Marshal()
+		if err != nil {
+			return err
+		}
+
+		i.resetChanges(av)
+
+		return afterSave(i.item)
+
+	case "Delete":
+		i.resetChanges(nil)
+	}
+
+	return nil
+}
+
+// Consistent snapshot read of items by primary key across registered tables, e.g.
+//
+//	items, err := d.TransactGet().
+//		Add("orders", order.Id).
+//		Add("users", order.UserId).
+//		All()
+//	order, user := items[0], items[1]
+type TransactGet struct {
+	dytona *Dytona
+
+	keys        []batchKey
+	withDeleted bool
+	err         error
+}
+
+// Starting a transactional read
+func (d *Dytona) TransactGet() *TransactGet {
+	return &TransactGet{dytona: d}
+}
+
+// Adding a key of the registered table, items are returned in the order keys were added
+func (g *TransactGet) Add(tableName string, hashKey interface{}, rangeKey ...interface{}) *TransactGet {
+	if g.err != nil {
+		return g
+	}
+
+	t := g.dytona.Table(tableName)
+	if t == nil {
+		g.err = fmt.Errorf("%w: '%s'", ErrorTableNotRegistered, tableName)
+		return g
+	}
+
+	var r interface{}
+	if len(rangeKey) > 0 {
+		r = rangeKey[0]
+	}
+
+	key, err := t.key(hashKey, r)
+	if err != nil {
+		g.err = err
+		return g
+	}
+
+	g.keys = append(g.keys, batchKey{table: t, key: key})
+
+	return g
+}
+
+// Including soft-deleted items into the results
+func (g *TransactGet) WithDeleted() *TransactGet {
+	g.withDeleted = true
+	return g
+}
+
+// Reading all the items at once, the result has an item per added key,
+// nil for the missing ones
+func (g *TransactGet) All() ([]Itemer, error) {
+	return g.AllWithContext(context.Background())
+}
+
+// Same as All() bound to the context
+func (g *TransactGet) AllWithContext(ctx context.Context) ([]Itemer, error) {
+	input, err := g.input()
+	if err != nil || len(input.TransactItems) == 0 {
+		return nil, err
+	}
+
+	session := g.dytona.GetSession()
+	if session == nil {
+		return nil, ErrorNoSession
+	}
+
+	out, err := session.TransactGetItemsWithContext(ctx, input)
+	if err != nil {
+		return nil, g.canceled(err)
+	}
+
+	items := make([]Itemer, len(g.keys))
+
+	for n, response := range out.Responses {
+		t := g.keys[n].table
+
+		if response == nil || len(response.Item) == 0 || (!g.withDeleted && !t.withDeleted && t.isDeleted(response.Item)) {
+			continue
+		}
+
+		if items[n], err = t.decodeItem(response.Item); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func (g *TransactGet) input() (*dynamodb.TransactGetItemsInput, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	if g.dytona.GetSession() == nil {
+		return nil, ErrorNoSession
+	}
+
+	if len(g.keys) > TransactLimit {
+		return nil, ErrorTransactionTooLarge
+	}
+
+	input := &dynamodb.TransactGetItemsInput{}
+	for _, k := range g.keys {
+		input.TransactItems = append(input.TransactItems, &dynamodb.TransactGetItem{
+			Get: &dynamodb.Get{
+				TableName: k.table.getDescription().TableName,
+				Key:       k.key,
+			},
+		})
+	}
+
+	return input, nil
+}
+
+// Matching cancellation reasons with the keys
+func (g *TransactGet) canceled(err error) error {
+	codes, messages, ok := cancellationReasons(err)
+	if !ok || len(codes) != len(g.keys) {
+		return classify(err)
+	}
+
+	reasons := make([]*CancellationReason, len(codes))
+	for n, code := range codes {
+		reasons[n] = &CancellationReason{
+			Index:     n,
+			Operation: "Get",
+			TableName: g.keys[n].table.Name(),
+			Code:      code,
+			Message:   messages[n],
+			Err:       cancellationError(code, messages[n]),
+		}
+	}
+
+	return &TransactionCanceledError{Reasons: reasons, Err: err}
+}
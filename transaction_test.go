@@ -0,0 +1,260 @@
+package dytona
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionInput(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Name    string `json:"name" dynamodbav:"name"`
+		Version int    `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+	tbl := d.RegisterTable("users", func() Itemer { return &User{} })
+
+	u1 := tbl.NewItem().(*User)
+	u1.Id = "1"
+
+	u2 := tbl.NewItem().(*User)
+	u2.Id = "2"
+	u2.Version = 3
+	u2.Set("Name", "Roman")
+
+	u3 := tbl.NewItem().(*User)
+	u3.Id = "3"
+
+	input, err := d.Transaction().
+		Put(u1).
+		Update(u2).
+		Delete(u3).
+		Check(u1, "#name = ?", "Roman").
+		WithToken("token").
+		input()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "token", *input.ClientRequestToken)
+	if assert.Len(t, input.TransactItems, 4) {
+		assert.Equal(t, "attribute_not_exists(#n0)", *input.TransactItems[0].Put.ConditionExpression)
+		assert.Equal(t, "1", *input.TransactItems[0].Put.Item["version"].N)
+		assert.Contains(t, *input.TransactItems[1].Update.UpdateExpression, "SET ")
+		assert.Equal(t, "2", *input.TransactItems[1].Update.Key["id"].S)
+		assert.Equal(t, "3", *input.TransactItems[2].Delete.Key["id"].S)
+		assert.Equal(t, "#n0 = :v0", *input.TransactItems[3].ConditionCheck.ConditionExpression)
+	}
+
+	// nothing to update
+	input, err = d.Transaction().Update(u3).input()
+	assert.Nil(t, err)
+	assert.Nil(t, input)
+
+	_, err = d.Transaction().Put(&User{}).Put(u1).input()
+	assert.Equal(t, ErrorItemNotSet, err)
+
+	tx := d.Transaction()
+	for n := 0; n <= TransactLimit; n++ {
+		tx.Check(u1, "attribute_exists(#id)")
+	}
+	_, err = tx.input()
+	assert.Equal(t, ErrorTransactionTooLarge, err)
+}
+
+func TestTransactionRestore(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name" validate:"required"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+	tbl := d.RegisterTable("users", func() Itemer { return &User{} })
+
+	u1 := tbl.NewItem().(*User)
+	u1.Name = "Roman"
+
+	u2 := tbl.NewItem().(*User)
+
+	err := d.Transaction().Put(u1).Put(u2).Commit()
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, "", u1.Id, "Not committed put should not touch the item")
+	assert.True(t, u1.CreatedAt.IsZero())
+	assert.Equal(t, "", u2.Id)
+}
+
+func TestTransactionCanceled(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Version int `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+	tbl := d.RegisterTable("users", func() Itemer { return &User{} })
+
+	u1 := tbl.NewItem().(*User)
+	u1.Id = "1"
+	u1.Version = 2
+
+	u2 := tbl.NewItem().(*User)
+	u2.Id = "2"
+
+	tx := d.Transaction().Put(u1).Check(u2, "attribute_exists(#id)").Delete(u2)
+
+	err := tx.canceled(context.Background(), d.GetSession(), &dynamodb.TransactionCanceledException{
+		CancellationReasons: []*dynamodb.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+			{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+			{Code: aws.String("None")},
+		},
+	})
+
+	assert.True(t, errors.Is(err, ErrTransactionCanceled))
+	if assert.IsType(t, &TransactionCanceledError{}, err) {
+		reasons := err.(*TransactionCanceledError).Reasons
+		assert.True(t, errors.Is(reasons[0].Err, ErrConditionFailed))
+		assertVersionConflict(t, reasons[0].Err, 2)
+		assert.Equal(t, "Put", reasons[0].Operation)
+		assert.Equal(t, ErrorConditionFailed, reasons[1].Err)
+		assert.Equal(t, u2, reasons[1].Item)
+		assert.Nil(t, reasons[2].Err)
+		assert.Equal(t, "Transaction canceled: #0 Put: Item in table 'users' was modified, expected version 2; "+
+			"#1 ConditionCheck: Condition check failed", err.Error())
+	}
+
+	other := awserr.New("ValidationException", "Invalid", nil)
+	err = tx.canceled(context.Background(), d.GetSession(), other)
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.False(t, errors.Is(err, ErrTransactionCanceled))
+
+	err = tx.canceled(context.Background(), d.GetSession(), awserr.New("TransactionCanceledException", "Transaction cancelled [None]", nil))
+	assert.True(t, errors.Is(err, ErrTransactionCanceled))
+	assert.IsType(t, &Error{}, err, "Reasons are only taken from TransactionCanceledException")
+}
+
+func TestTransaction(t *testing.T) {
+	type User struct {
+		Item    `json:"-" dynamodbav:"-"`
+		Stock   int `json:"stock" dynamodbav:"stock"`
+		Version int `json:"version" dynamodbav:"version" dynamodbversion:"true"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := d.RegisterTable("users", func() Itemer {
+		return &User{}
+	})
+
+	if err := tbl.Create(); err != nil {
+		assert.Nil(t, err, err.Error())
+	}
+	defer tbl.Delete()
+
+	u1 := tbl.NewItem().(*User)
+	u1.Id = "1"
+	u1.Stock = 1
+	assert.Nil(t, u1.Save())
+
+	u2 := tbl.NewItem().(*User)
+	u2.Id = "2"
+
+	u1.Set("Stock", 0)
+	assert.Nil(t, d.Transaction().Put(u2).UpdateIf(u1, "#stock > ?", 0).WithToken("1").Commit())
+	assert.Equal(t, 2, u1.Version)
+	assert.Equal(t, 1, u2.Version)
+
+	u1.Set("Stock", -1)
+	err := d.Transaction().UpdateIf(u1, "#stock > ?", 0).Delete(u2).Commit()
+	if assert.IsType(t, &TransactionCanceledError{}, err) {
+		assert.Equal(t, ErrorConditionFailed, err.(*TransactionCanceledError).Reasons[0].Err)
+		assert.Nil(t, err.(*TransactionCanceledError).Reasons[1].Err)
+	}
+
+	_, err = tbl.Get("2")
+	assert.Nil(t, err)
+}
+
+func TestTransactGetInput(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.RegisterTable("users", func() Itemer { return &User{} })
+
+	_, err := d.TransactGet().Add("users", "1").input()
+	assert.Equal(t, ErrorNoSession, err)
+
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	input, err := d.TransactGet().Add("users", "1").Add("Users", "2").input()
+	assert.Nil(t, err)
+	if assert.Len(t, input.TransactItems, 2) {
+		assert.Equal(t, "users", *input.TransactItems[1].Get.TableName)
+		assert.Equal(t, "2", *input.TransactItems[1].Get.Key["id"].S)
+	}
+
+	_, err = d.TransactGet().Add("accounts", "1").input()
+	assert.EqualError(t, err, "Table is not registered: 'accounts'")
+
+	g := d.TransactGet()
+	for n := 0; n <= TransactLimit; n++ {
+		g.Add("users", "1")
+	}
+	_, err = g.input()
+	assert.Equal(t, ErrorTransactionTooLarge, err)
+
+	items, err := d.TransactGet().All()
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+}
+
+func TestTransactGet(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name"`
+	}
+
+	type Event struct {
+		Item `json:"-" dynamodbav:"-"`
+	}
+
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	users := d.RegisterTable("users", func() Itemer { return &User{} })
+	events := d.RegisterTable("events", func() Itemer { return &Event{} })
+
+	for _, tbl := range []*Table{users, events} {
+		if err := tbl.Create(); err != nil {
+			assert.Nil(t, err, err.Error())
+		}
+		defer tbl.Delete()
+	}
+
+	u := users.NewItem().(*User)
+	u.Id = "1"
+	u.Name = "Roman"
+	assert.Nil(t, u.Save())
+
+	e := events.NewItem().(*Event)
+	e.Id = "1"
+	assert.Nil(t, e.Save())
+
+	items, err := d.TransactGet().Add("users", "1").Add("users", "2").Add("events", "1").All()
+	assert.Nil(t, err)
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, "Roman", items[0].(*User).Name)
+		assert.Nil(t, items[1])
+		assert.IsType(t, &Event{}, items[2])
+	}
+}
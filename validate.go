@@ -0,0 +1,318 @@
+package dytona
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Struct tag with comma separated validation rules checked before writes, e.g.
+//
+//	Name  string `dynamodbav:"name" validate:"required,min=2,max=64"`
+//	Role  string `dynamodbav:"role" validate:"oneof=admin user guest"`
+//	Email string `dynamodbav:"email" validate:"email"`
+//	Code  string `dynamodbav:"code" validate:"len=6,regex=^[0-9]+$"`
+//
+// `min`, `max` and `len` compare numbers by value and strings, slices and maps by length,
+// `regex` takes the rest of the tag so the expression may contain commas. Rules other than
+// `required` are not checked for empty values.
+const TagValidate = "validate"
+
+var (
+	emailRegexp *regexp.Regexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+	// compiled `regex` rules
+	regexps sync.Map
+)
+
+// Single failed rule of a field, Field and Attribute are empty for item level errors
+type FieldError struct {
+	Field     string
+	Attribute string
+	Rule      string
+	Message   string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("Field '%s' %s", e.Field, e.Message)
+}
+
+// Returned on write when the item has failed validation, holds all the failed rules
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for n, err := range e.Errors {
+		messages[n] = err.Error()
+	}
+
+	return "Validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Returned when a `validate` tag can't be parsed
+type ValidateTagError struct {
+	Field string
+	Rule  string
+	Err   error
+}
+
+func (e *ValidateTagError) Error() string {
+	return fmt.Sprintf("Field '%s' has malformed `%s` rule '%s': %s", e.Field, TagValidate, e.Rule, e.Err.Error())
+}
+
+// Custom item validation, override it on the item type to check the rules
+// tags can't express, e.g. dependencies between fields
+func (i *Item) Validate() error {
+	return nil
+}
+
+// Checking the `validate` tag rules of all the item's fields
+func (i *Item) DefaultValidate() error {
+	var (
+		rValue reflect.Value = reflect.ValueOf(i.item).Elem()
+		tp     reflect.Type  = rValue.Type()
+		errs   []*FieldError
+	)
+
+	for n := 0; n < tp.NumField(); n++ {
+		f := tp.Field(n)
+
+		rules, ok := f.Tag.Lookup(TagValidate)
+		if !ok || rules == "" || f.PkgPath != "" {
+			continue
+		}
+
+		attributeName := strings.Split(f.Tag.Get(TagAttributeValue), ",")[0]
+		if attributeName == "" {
+			attributeName = f.Name
+		}
+
+		fieldErrs, err := validateField(f.Name, attributeName, rValue.Field(n), rules)
+		if err != nil {
+			return err
+		}
+
+		errs = append(errs, fieldErrs...)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// Running both tag rules and custom validation, custom errors are merged
+// into a single ValidationError
+func (i *Item) validate() error {
+	var errs []*FieldError
+
+	switch err := i.item.DefaultValidate().(type) {
+	case nil:
+		break
+	case *ValidationError:
+		errs = append(errs, err.Errors...)
+	default:
+		return err
+	}
+
+	switch err := i.item.Validate().(type) {
+	case nil:
+		break
+	case *ValidationError:
+		errs = append(errs, err.Errors...)
+	case *FieldError:
+		errs = append(errs, err)
+	default:
+		errs = append(errs, &FieldError{Rule: "custom", Message: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+func validateField(name, attributeName string, rValue reflect.Value, rules string) ([]*FieldError, error) {
+	var errs []*FieldError
+
+	for rValue.Kind() == reflect.Ptr || rValue.Kind() == reflect.Interface {
+		if rValue.IsNil() {
+			break
+		}
+		rValue = rValue.Elem()
+	}
+
+	empty := isEmptyValue(rValue)
+
+	for rules != "" {
+		var rule string
+
+		// `regex` takes the rest of the rules
+		if strings.HasPrefix(rules, "regex=") {
+			rule, rules = rules, ""
+		} else if n := strings.Index(rules, ","); n >= 0 {
+			rule, rules = rules[:n], rules[n+1:]
+		} else {
+			rule, rules = rules, ""
+		}
+
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		ruleName, param := rule, ""
+		if n := strings.Index(rule, "="); n >= 0 {
+			ruleName, param = rule[:n], rule[n+1:]
+		}
+
+		if ruleName != "required" && empty {
+			continue
+		}
+
+		message, err := checkRule(ruleName, param, rValue)
+		if err != nil {
+			return nil, &ValidateTagError{Field: name, Rule: rule, Err: err}
+		}
+
+		if message != "" {
+			errs = append(errs, &FieldError{Field: name, Attribute: attributeName, Rule: ruleName, Message: message})
+		}
+	}
+
+	return errs, nil
+}
+
+// Getting the failure message of a single rule, empty if the value passes
+func checkRule(rule, param string, rValue reflect.Value) (string, error) {
+	switch rule {
+	case "required":
+		if isEmptyValue(rValue) {
+			return "is required", nil
+		}
+
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", err
+		}
+
+		size, isLength, err := valueSize(rValue)
+		if err != nil {
+			return "", err
+		}
+
+		what := "has to be"
+		if isLength {
+			what = "has to have length"
+		}
+
+		switch {
+		case rule == "min" && size < limit:
+			return fmt.Sprintf("%s at least %s", what, param), nil
+		case rule == "max" && size > limit:
+			return fmt.Sprintf("%s at most %s", what, param), nil
+		case rule == "len" && size != limit:
+			return fmt.Sprintf("%s exactly %s", what, param), nil
+		}
+
+	case "oneof":
+		value := fmt.Sprint(rValue.Interface())
+		for _, option := range strings.Fields(param) {
+			if value == option {
+				return "", nil
+			}
+		}
+
+		return fmt.Sprintf("has to be one of [%s]", strings.Join(strings.Fields(param), ", ")), nil
+
+	case "email":
+		if rValue.Kind() != reflect.String {
+			return "", fmt.Errorf("field has to be a string")
+		}
+
+		if !emailRegexp.MatchString(rValue.String()) {
+			return "has to be a valid email address", nil
+		}
+
+	case "regex":
+		if rValue.Kind() != reflect.String {
+			return "", fmt.Errorf("field has to be a string")
+		}
+
+		re, err := compileRegexp(param)
+		if err != nil {
+			return "", err
+		}
+
+		if !re.MatchString(rValue.String()) {
+			return fmt.Sprintf("has to match '%s'", param), nil
+		}
+
+	default:
+		return "", fmt.Errorf("unknown rule")
+	}
+
+	return "", nil
+}
+
+// Numbers are measured by value, the rest by length
+func valueSize(rValue reflect.Value) (size float64, isLength bool, err error) {
+	switch rValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rValue.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rValue.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return rValue.Float(), false, nil
+	case reflect.String:
+		return float64(len([]rune(rValue.String()))), true, nil
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(rValue.Len()), true, nil
+	}
+
+	return 0, false, fmt.Errorf("field of kind %s has no size", rValue.Kind())
+}
+
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	regexps.Store(expr, re)
+
+	return re, nil
+}
+
+func isEmptyValue(rValue reflect.Value) bool {
+	switch rValue.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rValue.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rValue.IsNil()
+	}
+
+	return reflect.DeepEqual(rValue.Interface(), reflect.Zero(rValue.Type()).Interface())
+}
@@ -0,0 +1,131 @@
+package dytona
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultValidate(t *testing.T) {
+	type User struct {
+		Item  `json:"-" dynamodbav:"-"`
+		Name  string   `json:"name" dynamodbav:"name" validate:"required,min=2,max=5"`
+		Age   int      `json:"age" dynamodbav:"age" validate:"min=18,max=99"`
+		Role  string   `json:"role" dynamodbav:"role" validate:"oneof=admin user"`
+		Email string   `json:"email" dynamodbav:"email" validate:"email"`
+		Code  *string  `json:"code" dynamodbav:"code" validate:"len=3,regex=^[0-9]{1,3}$"`
+		Tags  []string `json:"tags" dynamodbav:"tags" validate:"max=1"`
+	}
+
+	u := &User{}
+	u.SetItem(u)
+
+	err := u.DefaultValidate()
+	assert.Equal(t, &ValidationError{Errors: []*FieldError{
+		{Field: "Name", Attribute: "name", Rule: "required", Message: "is required"},
+	}}, err)
+
+	code := "12a4"
+	u.Name = "Roman Minkin"
+	u.Age = 10
+	u.Role = "root"
+	u.Email = "roman"
+	u.Code = &code
+	u.Tags = []string{"a", "b"}
+
+	err = u.DefaultValidate()
+	assert.Equal(t, &ValidationError{Errors: []*FieldError{
+		{Field: "Name", Attribute: "name", Rule: "max", Message: "has to have length at most 5"},
+		{Field: "Age", Attribute: "age", Rule: "min", Message: "has to be at least 18"},
+		{Field: "Role", Attribute: "role", Rule: "oneof", Message: "has to be one of [admin, user]"},
+		{Field: "Email", Attribute: "email", Rule: "email", Message: "has to be a valid email address"},
+		{Field: "Code", Attribute: "code", Rule: "len", Message: "has to have length exactly 3"},
+		{Field: "Code", Attribute: "code", Rule: "regex", Message: "has to match '^[0-9]{1,3}$'"},
+		{Field: "Tags", Attribute: "tags", Rule: "max", Message: "has to have length at most 1"},
+	}}, err)
+	assert.Equal(t, "Validation failed: Field 'Name' has to have length at most 5; Field 'Age' has to be at least 18; "+
+		"Field 'Role' has to be one of [admin, user]; Field 'Email' has to be a valid email address; "+
+		"Field 'Code' has to have length exactly 3; Field 'Code' has to match '^[0-9]{1,3}$'; "+
+		"Field 'Tags' has to have length at most 1", err.Error())
+
+	code = "123"
+	u.Name = "Roman"
+	u.Age = 33
+	u.Role = "admin"
+	u.Email = "roman@example.com"
+	u.Tags = nil
+	assert.Nil(t, u.DefaultValidate())
+}
+
+func TestDefaultValidateMalformed(t *testing.T) {
+	type User struct {
+		Item `json:"-" dynamodbav:"-"`
+		Name string `json:"name" dynamodbav:"name" validate:"min=two"`
+		Age  int    `json:"age" dynamodbav:"age" validate:"unique"`
+	}
+
+	u := &User{Name: "Roman"}
+	u.SetItem(u)
+
+	err := u.DefaultValidate()
+	if assert.IsType(t, &ValidateTagError{}, err) {
+		assert.Equal(t, "Name", err.(*ValidateTagError).Field)
+		assert.Equal(t, "min=two", err.(*ValidateTagError).Rule)
+	}
+
+	u.Name = ""
+	u.Age = 1
+	err = u.DefaultValidate()
+	assert.EqualError(t, err, "Field 'Age' has malformed `validate` rule 'unique': unknown rule")
+}
+
+type validatedUser struct {
+	Item     `json:"-" dynamodbav:"-"`
+	Password string `json:"password" dynamodbav:"password" validate:"required"`
+	Confirm  string `json:"-" dynamodbav:"-"`
+}
+
+func (u *validatedUser) Validate() error {
+	if u.Password != u.Confirm {
+		return &FieldError{Field: "Confirm", Rule: "custom", Message: "has to match the password"}
+	}
+
+	return nil
+}
+
+type customValidatedUser struct {
+	Item `json:"-" dynamodbav:"-"`
+}
+
+func (u *customValidatedUser) Validate() error {
+	return errors.New("Not allowed")
+}
+
+func TestValidate(t *testing.T) {
+	d := NewDytona("key", "secret", "http://localhost:8000", "us-east-1")
+	d.Dial(NewConfig().WithMaxRetries(0))
+
+	tbl := NewTable("users", func() Itemer {
+		return &validatedUser{}
+	}).WithSession(d.session)
+
+	u := tbl.NewItem().(*validatedUser)
+	u.Confirm = "secret"
+
+	assert.Equal(t, &ValidationError{Errors: []*FieldError{
+		{Field: "Password", Attribute: "password", Rule: "required", Message: "is required"},
+		{Field: "Confirm", Rule: "custom", Message: "has to match the password"},
+	}}, u.Save())
+
+	assert.IsType(t, &ValidationError{}, u.Update())
+	assert.Equal(t, "", u.Id, "Invalid item should not be touched")
+
+	c := NewTable("users", func() Itemer {
+		return &customValidatedUser{}
+	}).WithSession(d.session).NewItem()
+
+	assert.Equal(t, &ValidationError{Errors: []*FieldError{
+		{Rule: "custom", Message: "Not allowed"},
+	}}, c.Save())
+}